@@ -12,5 +12,10 @@ func main() {
 
 	dir.CreateDir()
 
+	if *config.Purge {
+		server.Purge()
+		return
+	}
+
 	server.StartServer()
 }
@@ -7,17 +7,37 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // CHANGE LOGGING
 var (
-	Port *string
-	Dir  *string
+	Port            *string
+	Dir             *string
+	AlertWebhook    *string
+	ExpiryInterval  *time.Duration
+	TimeZone        *string
+	Purge           *bool
+	Retention       *time.Duration
+	EventBuffer     *int
+	WebhookAttempts *int
+	WebhookBackoff  *time.Duration
+
+	// Location is the shop's time zone, loaded from TimeZone.
+	Location *time.Location
 )
 
 func ParseFlags() {
 	Port = flag.String("port", "8080", "Port number")
 	Dir = flag.String("dir", "data", "Path to the data directory")
+	AlertWebhook = flag.String("alert-webhook", "", "URL notified when an ingredient crosses its minimum level")
+	ExpiryInterval = flag.Duration("expiry-interval", time.Hour, "How often expired lots are written off")
+	TimeZone = flag.String("timezone", "Local", "Time zone of the shop for menu schedules")
+	Purge = flag.Bool("purge", false, "Remove records archived longer than the retention period and exit")
+	Retention = flag.Duration("retention", 90*24*time.Hour, "How long archived records are kept before --purge removes them")
+	EventBuffer = flag.Int("event-buffer", 1000, "How many events are kept for clients resuming GET /events")
+	WebhookAttempts = flag.Int("webhook-attempts", 5, "How many times a webhook delivery is attempted before it is dead")
+	WebhookBackoff = flag.Duration("webhook-backoff", 30*time.Second, "Wait before the first webhook retry, doubled after every failed attempt")
 	help := flag.Bool("help", false, "Show help screen")
 	flag.Parse()
 
@@ -29,13 +49,22 @@ func ParseFlags() {
 		fmt.Println(`Coffee Shop Management System
 
 		Usage:
-		  hot-coffee [--port <N>] [--dir <S>] 
+		  hot-coffee [--port <N>] [--dir <S>] [--alert-webhook <URL>] [--expiry-interval <D>] [--timezone <TZ>] [--event-buffer <N>] [--webhook-attempts <N>] [--webhook-backoff <D>]
+		  hot-coffee --purge [--retention <D>] [--dir <S>]
 		  hot-coffee --help
 		
 		Options:
-		  --help       Show this screen.
-		  --port N     Port number.
-		  --dir S      Path to the data directory.`)
+		  --help                 Show this screen.
+		  --port N               Port number.
+		  --dir S                Path to the data directory.
+		  --alert-webhook URL    URL notified when an ingredient crosses its minimum level.
+		  --expiry-interval D    How often expired lots are written off (default 1h).
+		  --timezone TZ          Time zone of the shop for menu schedules, e.g. Europe/Berlin (default Local).
+		  --event-buffer N       How many events are kept for clients resuming GET /events (default 1000).
+		  --webhook-attempts N   How many times a webhook delivery is attempted before it is dead (default 5).
+		  --webhook-backoff D    Wait before the first webhook retry, doubled after every failed attempt (default 30s).
+		  --purge                Remove records archived longer than the retention period and exit.
+		  --retention D          How long archived records are kept (default 2160h, 90 days).`)
 
 		os.Exit(0)
 	}
@@ -47,6 +76,32 @@ func ParseFlags() {
 	if err := validatePort(); err != nil {
 		log.Fatal(err)
 	}
+
+	if *Retention < 0 {
+		log.Fatal(fmt.Errorf("retention must not be negative"))
+	}
+
+	if *ExpiryInterval <= 0 {
+		log.Fatal(fmt.Errorf("expiry interval must be positive"))
+	}
+
+	if *EventBuffer <= 0 {
+		log.Fatal(fmt.Errorf("event buffer must be positive"))
+	}
+
+	if *WebhookAttempts <= 0 {
+		log.Fatal(fmt.Errorf("webhook attempts must be positive"))
+	}
+
+	if *WebhookBackoff <= 0 {
+		log.Fatal(fmt.Errorf("webhook backoff must be positive"))
+	}
+
+	location, err := time.LoadLocation(*TimeZone)
+	if err != nil {
+		log.Fatal(fmt.Errorf("unknown time zone %q", *TimeZone))
+	}
+	Location = location
 }
 
 func validatePort() error {
@@ -0,0 +1,104 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type AlertRepository interface {
+	GetAlerts() ([]models.StockAlert, error)
+	GetAlertID(id string) (models.StockAlert, error)
+	CreateAlert(newAlert models.StockAlert) error
+	UpdateAlert(id string, newAlert models.StockAlert) error
+}
+
+type jsonAlertRepository struct {
+	filepath string
+}
+
+func NewAlertRepository(filepath string) AlertRepository {
+	return &jsonAlertRepository{filepath: filepath}
+}
+
+func (a *jsonAlertRepository) GetAlerts() ([]models.StockAlert, error) {
+	byteValue, err := utils.ReadFile(a.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", a.filepath)
+		return []models.StockAlert{}, myerrors.ErrFailOpenJson
+	}
+
+	var alerts []models.StockAlert
+	if err := json.Unmarshal(byteValue, &alerts); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.StockAlert{}, myerrors.ErrFailUnmarshal
+	}
+
+	return alerts, nil
+}
+
+func (a *jsonAlertRepository) GetAlertID(id string) (models.StockAlert, error) {
+	alerts, err := a.GetAlerts()
+	if err != nil {
+		return models.StockAlert{}, err
+	}
+
+	for _, alert := range alerts {
+		if alert.ID == id {
+			return alert, nil
+		}
+	}
+
+	return models.StockAlert{}, myerrors.ErrNotFound
+}
+
+func (a *jsonAlertRepository) CreateAlert(newAlert models.StockAlert) error {
+	alerts, err := a.GetAlerts()
+	if err != nil {
+		return err
+	}
+
+	return a.save(append(alerts, newAlert))
+}
+
+func (a *jsonAlertRepository) UpdateAlert(id string, newAlert models.StockAlert) error {
+	alerts, err := a.GetAlerts()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range alerts {
+		if alerts[i].ID == id {
+			alerts[i] = newAlert
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return a.save(alerts)
+}
+
+func (a *jsonAlertRepository) save(alerts []models.StockAlert) error {
+	filestring, err := json.MarshalIndent(alerts, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+a.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", a.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CategoryRepository interface {
+	GetCategories() ([]models.Category, error)
+	GetCategoryID(id string) (models.Category, error)
+	CreateCategory(newCategory models.Category) error
+	UpdateCategory(id string, newCategory models.Category) error
+	DeleteCategory(id string) error
+}
+
+type jsonCategoryRepository struct {
+	filepath string
+}
+
+func NewCategoryRepository(filepath string) CategoryRepository {
+	return &jsonCategoryRepository{filepath: filepath}
+}
+
+func (r *jsonCategoryRepository) GetCategories() ([]models.Category, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Category{}, myerrors.ErrFailOpenJson
+	}
+
+	var categories []models.Category
+	if err := json.Unmarshal(byteValue, &categories); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Category{}, myerrors.ErrFailUnmarshal
+	}
+
+	return categories, nil
+}
+
+func (r *jsonCategoryRepository) GetCategoryID(id string) (models.Category, error) {
+	categories, err := r.GetCategories()
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	for _, category := range categories {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+
+	return models.Category{}, myerrors.ErrNotFound
+}
+
+func (r *jsonCategoryRepository) CreateCategory(newCategory models.Category) error {
+	categories, err := r.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(categories, newCategory))
+}
+
+func (r *jsonCategoryRepository) UpdateCategory(id string, newCategory models.Category) error {
+	categories, err := r.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range categories {
+		if categories[i].ID == id {
+			categories[i] = newCategory
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(categories)
+}
+
+func (r *jsonCategoryRepository) DeleteCategory(id string) error {
+	categories, err := r.GetCategories()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newCategories := []models.Category{}
+	for i := range categories {
+		if categories[i].ID == id {
+			isFound = true
+			continue
+		}
+		newCategories = append(newCategories, categories[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newCategories)
+}
+
+func (r *jsonCategoryRepository) save(categories []models.Category) error {
+	filestring, err := json.MarshalIndent(categories, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
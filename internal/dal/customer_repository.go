@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CustomerRepository interface {
+	GetCustomers() ([]models.Customer, error)
+	GetCustomerID(id string) (models.Customer, error)
+	CreateCustomer(newCustomer models.Customer) error
+	UpdateCustomer(id string, newCustomer models.Customer) error
+	DeleteCustomer(id string) error
+}
+
+type jsonCustomerRepository struct {
+	filepath string
+}
+
+func NewCustomerRepository(filepath string) CustomerRepository {
+	return &jsonCustomerRepository{filepath: filepath}
+}
+
+func (r *jsonCustomerRepository) GetCustomers() ([]models.Customer, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Customer{}, myerrors.ErrFailOpenJson
+	}
+
+	var customers []models.Customer
+	if err := json.Unmarshal(byteValue, &customers); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Customer{}, myerrors.ErrFailUnmarshal
+	}
+
+	return customers, nil
+}
+
+func (r *jsonCustomerRepository) GetCustomerID(id string) (models.Customer, error) {
+	customers, err := r.GetCustomers()
+	if err != nil {
+		return models.Customer{}, err
+	}
+
+	for _, customer := range customers {
+		if customer.ID == id {
+			return customer, nil
+		}
+	}
+
+	return models.Customer{}, myerrors.ErrNotFound
+}
+
+func (r *jsonCustomerRepository) CreateCustomer(newCustomer models.Customer) error {
+	customers, err := r.GetCustomers()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(customers, newCustomer))
+}
+
+func (r *jsonCustomerRepository) UpdateCustomer(id string, newCustomer models.Customer) error {
+	customers, err := r.GetCustomers()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range customers {
+		if customers[i].ID == id {
+			customers[i] = newCustomer
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(customers)
+}
+
+func (r *jsonCustomerRepository) DeleteCustomer(id string) error {
+	customers, err := r.GetCustomers()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newCustomers := []models.Customer{}
+	for i := range customers {
+		if customers[i].ID == id {
+			isFound = true
+			continue
+		}
+		newCustomers = append(newCustomers, customers[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newCustomers)
+}
+
+func (r *jsonCustomerRepository) save(customers []models.Customer) error {
+	filestring, err := json.MarshalIndent(customers, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type GiftCardRepository interface {
+	GetGiftCards() ([]models.GiftCard, error)
+	GetGiftCardID(id string) (models.GiftCard, error)
+	CreateGiftCard(newGiftCard models.GiftCard) error
+	UpdateGiftCard(id string, newGiftCard models.GiftCard) error
+	DeleteGiftCard(id string) error
+}
+
+type jsonGiftCardRepository struct {
+	filepath string
+}
+
+func NewGiftCardRepository(filepath string) GiftCardRepository {
+	return &jsonGiftCardRepository{filepath: filepath}
+}
+
+func (r *jsonGiftCardRepository) GetGiftCards() ([]models.GiftCard, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.GiftCard{}, myerrors.ErrFailOpenJson
+	}
+
+	var giftCards []models.GiftCard
+	if err := json.Unmarshal(byteValue, &giftCards); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.GiftCard{}, myerrors.ErrFailUnmarshal
+	}
+
+	return giftCards, nil
+}
+
+func (r *jsonGiftCardRepository) GetGiftCardID(id string) (models.GiftCard, error) {
+	giftCards, err := r.GetGiftCards()
+	if err != nil {
+		return models.GiftCard{}, err
+	}
+
+	for _, giftCard := range giftCards {
+		if giftCard.Code == id {
+			return giftCard, nil
+		}
+	}
+
+	return models.GiftCard{}, myerrors.ErrNotFound
+}
+
+func (r *jsonGiftCardRepository) CreateGiftCard(newGiftCard models.GiftCard) error {
+	giftCards, err := r.GetGiftCards()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(giftCards, newGiftCard))
+}
+
+func (r *jsonGiftCardRepository) UpdateGiftCard(id string, newGiftCard models.GiftCard) error {
+	giftCards, err := r.GetGiftCards()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range giftCards {
+		if giftCards[i].Code == id {
+			giftCards[i] = newGiftCard
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(giftCards)
+}
+
+func (r *jsonGiftCardRepository) DeleteGiftCard(id string) error {
+	giftCards, err := r.GetGiftCards()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newGiftCards := []models.GiftCard{}
+	for i := range giftCards {
+		if giftCards[i].Code == id {
+			isFound = true
+			continue
+		}
+		newGiftCards = append(newGiftCards, giftCards[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newGiftCards)
+}
+
+func (r *jsonGiftCardRepository) save(giftCards []models.GiftCard) error {
+	filestring, err := json.MarshalIndent(giftCards, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
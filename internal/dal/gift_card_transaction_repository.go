@@ -0,0 +1,81 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type GiftCardTransactionRepository interface {
+	GetGiftCardTransactions() ([]models.GiftCardTransaction, error)
+	GetGiftCardTransactionID(id string) (models.GiftCardTransaction, error)
+	CreateGiftCardTransaction(newGiftCardTransaction models.GiftCardTransaction) error
+}
+
+type jsonGiftCardTransactionRepository struct {
+	filepath string
+}
+
+func NewGiftCardTransactionRepository(filepath string) GiftCardTransactionRepository {
+	return &jsonGiftCardTransactionRepository{filepath: filepath}
+}
+
+func (r *jsonGiftCardTransactionRepository) GetGiftCardTransactions() ([]models.GiftCardTransaction, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.GiftCardTransaction{}, myerrors.ErrFailOpenJson
+	}
+
+	var giftCardTransactions []models.GiftCardTransaction
+	if err := json.Unmarshal(byteValue, &giftCardTransactions); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.GiftCardTransaction{}, myerrors.ErrFailUnmarshal
+	}
+
+	return giftCardTransactions, nil
+}
+
+func (r *jsonGiftCardTransactionRepository) GetGiftCardTransactionID(id string) (models.GiftCardTransaction, error) {
+	giftCardTransactions, err := r.GetGiftCardTransactions()
+	if err != nil {
+		return models.GiftCardTransaction{}, err
+	}
+
+	for _, giftCardTransaction := range giftCardTransactions {
+		if giftCardTransaction.ID == id {
+			return giftCardTransaction, nil
+		}
+	}
+
+	return models.GiftCardTransaction{}, myerrors.ErrNotFound
+}
+
+func (r *jsonGiftCardTransactionRepository) CreateGiftCardTransaction(newGiftCardTransaction models.GiftCardTransaction) error {
+	giftCardTransactions, err := r.GetGiftCardTransactions()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(giftCardTransactions, newGiftCardTransaction))
+}
+
+func (r *jsonGiftCardTransactionRepository) save(giftCardTransactions []models.GiftCardTransaction) error {
+	filestring, err := json.MarshalIndent(giftCardTransactions, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
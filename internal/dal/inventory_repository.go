@@ -17,6 +17,7 @@ type InventoryRepository interface {
 	CreateInventory(newInventoryItem models.InventoryItem) error
 	UpdateInventory(id string, newInvItem models.InventoryItem) error
 	DeleteInventory(id string) error
+	SaveInventory(inventoryItems []models.InventoryItem) error
 }
 
 type jsonInventoryRepository struct {
@@ -156,3 +157,19 @@ func (o *jsonInventoryRepository) DeleteInventory(id string) error {
 
 	return nil
 }
+
+// SaveInventory replaces the whole file at once, so a batch of changes is applied entirely or not at all.
+func (o *jsonInventoryRepository) SaveInventory(inventoryItems []models.InventoryItem) error {
+	filestring, err := json.MarshalIndent(inventoryItems, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+o.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", o.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LotRepository interface {
+	GetLots() ([]models.InventoryLot, error)
+	GetLotID(id string) (models.InventoryLot, error)
+	CreateLot(newLot models.InventoryLot) error
+	UpdateLot(id string, newLot models.InventoryLot) error
+	DeleteLot(id string) error
+}
+
+type jsonLotRepository struct {
+	filepath string
+}
+
+func NewLotRepository(filepath string) LotRepository {
+	return &jsonLotRepository{filepath: filepath}
+}
+
+func (r *jsonLotRepository) GetLots() ([]models.InventoryLot, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.InventoryLot{}, myerrors.ErrFailOpenJson
+	}
+
+	var lots []models.InventoryLot
+	if err := json.Unmarshal(byteValue, &lots); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.InventoryLot{}, myerrors.ErrFailUnmarshal
+	}
+
+	return lots, nil
+}
+
+func (r *jsonLotRepository) GetLotID(id string) (models.InventoryLot, error) {
+	lots, err := r.GetLots()
+	if err != nil {
+		return models.InventoryLot{}, err
+	}
+
+	for _, lot := range lots {
+		if lot.ID == id {
+			return lot, nil
+		}
+	}
+
+	return models.InventoryLot{}, myerrors.ErrNotFound
+}
+
+func (r *jsonLotRepository) CreateLot(newLot models.InventoryLot) error {
+	lots, err := r.GetLots()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(lots, newLot))
+}
+
+func (r *jsonLotRepository) UpdateLot(id string, newLot models.InventoryLot) error {
+	lots, err := r.GetLots()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range lots {
+		if lots[i].ID == id {
+			lots[i] = newLot
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(lots)
+}
+
+func (r *jsonLotRepository) DeleteLot(id string) error {
+	lots, err := r.GetLots()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newLots := []models.InventoryLot{}
+	for i := range lots {
+		if lots[i].ID == id {
+			isFound = true
+			continue
+		}
+		newLots = append(newLots, lots[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newLots)
+}
+
+func (r *jsonLotRepository) save(lots []models.InventoryLot) error {
+	filestring, err := json.MarshalIndent(lots, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
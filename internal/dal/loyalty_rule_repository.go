@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LoyaltyRuleRepository interface {
+	GetLoyaltyRules() ([]models.LoyaltyRule, error)
+	GetLoyaltyRuleID(id string) (models.LoyaltyRule, error)
+	CreateLoyaltyRule(newLoyaltyRule models.LoyaltyRule) error
+	UpdateLoyaltyRule(id string, newLoyaltyRule models.LoyaltyRule) error
+	DeleteLoyaltyRule(id string) error
+}
+
+type jsonLoyaltyRuleRepository struct {
+	filepath string
+}
+
+func NewLoyaltyRuleRepository(filepath string) LoyaltyRuleRepository {
+	return &jsonLoyaltyRuleRepository{filepath: filepath}
+}
+
+func (r *jsonLoyaltyRuleRepository) GetLoyaltyRules() ([]models.LoyaltyRule, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.LoyaltyRule{}, myerrors.ErrFailOpenJson
+	}
+
+	var loyaltyRules []models.LoyaltyRule
+	if err := json.Unmarshal(byteValue, &loyaltyRules); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.LoyaltyRule{}, myerrors.ErrFailUnmarshal
+	}
+
+	return loyaltyRules, nil
+}
+
+func (r *jsonLoyaltyRuleRepository) GetLoyaltyRuleID(id string) (models.LoyaltyRule, error) {
+	loyaltyRules, err := r.GetLoyaltyRules()
+	if err != nil {
+		return models.LoyaltyRule{}, err
+	}
+
+	for _, loyaltyRule := range loyaltyRules {
+		if loyaltyRule.ID == id {
+			return loyaltyRule, nil
+		}
+	}
+
+	return models.LoyaltyRule{}, myerrors.ErrNotFound
+}
+
+func (r *jsonLoyaltyRuleRepository) CreateLoyaltyRule(newLoyaltyRule models.LoyaltyRule) error {
+	loyaltyRules, err := r.GetLoyaltyRules()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(loyaltyRules, newLoyaltyRule))
+}
+
+func (r *jsonLoyaltyRuleRepository) UpdateLoyaltyRule(id string, newLoyaltyRule models.LoyaltyRule) error {
+	loyaltyRules, err := r.GetLoyaltyRules()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range loyaltyRules {
+		if loyaltyRules[i].ID == id {
+			loyaltyRules[i] = newLoyaltyRule
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(loyaltyRules)
+}
+
+func (r *jsonLoyaltyRuleRepository) DeleteLoyaltyRule(id string) error {
+	loyaltyRules, err := r.GetLoyaltyRules()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newLoyaltyRules := []models.LoyaltyRule{}
+	for i := range loyaltyRules {
+		if loyaltyRules[i].ID == id {
+			isFound = true
+			continue
+		}
+		newLoyaltyRules = append(newLoyaltyRules, loyaltyRules[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newLoyaltyRules)
+}
+
+func (r *jsonLoyaltyRuleRepository) save(loyaltyRules []models.LoyaltyRule) error {
+	filestring, err := json.MarshalIndent(loyaltyRules, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
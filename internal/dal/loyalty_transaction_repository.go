@@ -0,0 +1,81 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LoyaltyTransactionRepository interface {
+	GetLoyaltyTransactions() ([]models.LoyaltyTransaction, error)
+	GetLoyaltyTransactionID(id string) (models.LoyaltyTransaction, error)
+	CreateLoyaltyTransaction(newLoyaltyTransaction models.LoyaltyTransaction) error
+}
+
+type jsonLoyaltyTransactionRepository struct {
+	filepath string
+}
+
+func NewLoyaltyTransactionRepository(filepath string) LoyaltyTransactionRepository {
+	return &jsonLoyaltyTransactionRepository{filepath: filepath}
+}
+
+func (r *jsonLoyaltyTransactionRepository) GetLoyaltyTransactions() ([]models.LoyaltyTransaction, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.LoyaltyTransaction{}, myerrors.ErrFailOpenJson
+	}
+
+	var loyaltyTransactions []models.LoyaltyTransaction
+	if err := json.Unmarshal(byteValue, &loyaltyTransactions); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.LoyaltyTransaction{}, myerrors.ErrFailUnmarshal
+	}
+
+	return loyaltyTransactions, nil
+}
+
+func (r *jsonLoyaltyTransactionRepository) GetLoyaltyTransactionID(id string) (models.LoyaltyTransaction, error) {
+	loyaltyTransactions, err := r.GetLoyaltyTransactions()
+	if err != nil {
+		return models.LoyaltyTransaction{}, err
+	}
+
+	for _, loyaltyTransaction := range loyaltyTransactions {
+		if loyaltyTransaction.ID == id {
+			return loyaltyTransaction, nil
+		}
+	}
+
+	return models.LoyaltyTransaction{}, myerrors.ErrNotFound
+}
+
+func (r *jsonLoyaltyTransactionRepository) CreateLoyaltyTransaction(newLoyaltyTransaction models.LoyaltyTransaction) error {
+	loyaltyTransactions, err := r.GetLoyaltyTransactions()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(loyaltyTransactions, newLoyaltyTransaction))
+}
+
+func (r *jsonLoyaltyTransactionRepository) save(loyaltyTransactions []models.LoyaltyTransaction) error {
+	filestring, err := json.MarshalIndent(loyaltyTransactions, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@ type MenuRepository interface {
 	CreateMenu(newMenuItem models.MenuItem) error
 	UpdateMenu(id string, newMenu models.MenuItem) error
 	DeleteMenu(id string) error
+	SaveMenu(menuItems []models.MenuItem) error
 }
 
 type jsonMenuRepository struct {
@@ -163,3 +164,19 @@ func (o *jsonMenuRepository) DeleteMenu(id string) error {
 
 	return nil
 }
+
+// SaveMenu replaces the whole file at once, so a batch of changes is applied entirely or not at all.
+func (o *jsonMenuRepository) SaveMenu(menuItems []models.MenuItem) error {
+	filestring, err := json.MarshalIndent(menuItems, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+o.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", o.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
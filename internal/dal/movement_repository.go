@@ -0,0 +1,62 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type MovementRepository interface {
+	GetMovements() ([]models.InventoryMovement, error)
+	CreateMovements(newMovements []models.InventoryMovement) error
+}
+
+type jsonMovementRepository struct {
+	filepath string
+}
+
+func NewMovementRepository(filepath string) MovementRepository {
+	return &jsonMovementRepository{filepath: filepath}
+}
+
+func (m *jsonMovementRepository) GetMovements() ([]models.InventoryMovement, error) {
+	byteValue, err := utils.ReadFile(m.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", m.filepath)
+		return []models.InventoryMovement{}, myerrors.ErrFailOpenJson
+	}
+
+	var movements []models.InventoryMovement
+	if err := json.Unmarshal(byteValue, &movements); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.InventoryMovement{}, myerrors.ErrFailUnmarshal
+	}
+
+	return movements, nil
+}
+
+func (m *jsonMovementRepository) CreateMovements(newMovements []models.InventoryMovement) error {
+	movements, err := m.GetMovements()
+	if err != nil {
+		return err
+	}
+	movements = append(movements, newMovements...)
+
+	filestring, err := json.MarshalIndent(movements, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+m.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", m.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"hot-coffee/models"
 	"log/slog"
 	"os"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
@@ -114,6 +115,7 @@ func (o *jsonOrderRepository) CloseOrder(id string) error {
 			isFound = true
 			if orders[i].Status == "open" {
 				orders[i].Status = "closed"
+				orders[i].ClosedAt = time.Now().Format(time.RFC3339)
 			} else {
 				slog.Error("Failed to close order", "error", myerrors.ErrOrderClosed)
 				return myerrors.ErrOrderClosed
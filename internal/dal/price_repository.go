@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PriceRepository interface {
+	GetPrices() ([]models.PriceChange, error)
+	GetPriceID(id string) (models.PriceChange, error)
+	CreatePrice(newPrice models.PriceChange) error
+	UpdatePrice(id string, newPrice models.PriceChange) error
+	DeletePrice(id string) error
+}
+
+type jsonPriceRepository struct {
+	filepath string
+}
+
+func NewPriceRepository(filepath string) PriceRepository {
+	return &jsonPriceRepository{filepath: filepath}
+}
+
+func (r *jsonPriceRepository) GetPrices() ([]models.PriceChange, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.PriceChange{}, myerrors.ErrFailOpenJson
+	}
+
+	var prices []models.PriceChange
+	if err := json.Unmarshal(byteValue, &prices); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.PriceChange{}, myerrors.ErrFailUnmarshal
+	}
+
+	return prices, nil
+}
+
+func (r *jsonPriceRepository) GetPriceID(id string) (models.PriceChange, error) {
+	prices, err := r.GetPrices()
+	if err != nil {
+		return models.PriceChange{}, err
+	}
+
+	for _, price := range prices {
+		if price.ID == id {
+			return price, nil
+		}
+	}
+
+	return models.PriceChange{}, myerrors.ErrNotFound
+}
+
+func (r *jsonPriceRepository) CreatePrice(newPrice models.PriceChange) error {
+	prices, err := r.GetPrices()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(prices, newPrice))
+}
+
+func (r *jsonPriceRepository) UpdatePrice(id string, newPrice models.PriceChange) error {
+	prices, err := r.GetPrices()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range prices {
+		if prices[i].ID == id {
+			prices[i] = newPrice
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(prices)
+}
+
+func (r *jsonPriceRepository) DeletePrice(id string) error {
+	prices, err := r.GetPrices()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newPrices := []models.PriceChange{}
+	for i := range prices {
+		if prices[i].ID == id {
+			isFound = true
+			continue
+		}
+		newPrices = append(newPrices, prices[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newPrices)
+}
+
+func (r *jsonPriceRepository) save(prices []models.PriceChange) error {
+	filestring, err := json.MarshalIndent(prices, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
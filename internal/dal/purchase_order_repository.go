@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PurchaseOrderRepository interface {
+	GetPurchaseOrders() ([]models.PurchaseOrder, error)
+	GetPurchaseOrderID(id string) (models.PurchaseOrder, error)
+	CreatePurchaseOrder(newPurchaseOrder models.PurchaseOrder) error
+	UpdatePurchaseOrder(id string, newPurchaseOrder models.PurchaseOrder) error
+	DeletePurchaseOrder(id string) error
+}
+
+type jsonPurchaseOrderRepository struct {
+	filepath string
+}
+
+func NewPurchaseOrderRepository(filepath string) PurchaseOrderRepository {
+	return &jsonPurchaseOrderRepository{filepath: filepath}
+}
+
+func (r *jsonPurchaseOrderRepository) GetPurchaseOrders() ([]models.PurchaseOrder, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.PurchaseOrder{}, myerrors.ErrFailOpenJson
+	}
+
+	var purchaseOrders []models.PurchaseOrder
+	if err := json.Unmarshal(byteValue, &purchaseOrders); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.PurchaseOrder{}, myerrors.ErrFailUnmarshal
+	}
+
+	return purchaseOrders, nil
+}
+
+func (r *jsonPurchaseOrderRepository) GetPurchaseOrderID(id string) (models.PurchaseOrder, error) {
+	purchaseOrders, err := r.GetPurchaseOrders()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	for _, purchaseOrder := range purchaseOrders {
+		if purchaseOrder.ID == id {
+			return purchaseOrder, nil
+		}
+	}
+
+	return models.PurchaseOrder{}, myerrors.ErrNotFound
+}
+
+func (r *jsonPurchaseOrderRepository) CreatePurchaseOrder(newPurchaseOrder models.PurchaseOrder) error {
+	purchaseOrders, err := r.GetPurchaseOrders()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(purchaseOrders, newPurchaseOrder))
+}
+
+func (r *jsonPurchaseOrderRepository) UpdatePurchaseOrder(id string, newPurchaseOrder models.PurchaseOrder) error {
+	purchaseOrders, err := r.GetPurchaseOrders()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range purchaseOrders {
+		if purchaseOrders[i].ID == id {
+			purchaseOrders[i] = newPurchaseOrder
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(purchaseOrders)
+}
+
+func (r *jsonPurchaseOrderRepository) DeletePurchaseOrder(id string) error {
+	purchaseOrders, err := r.GetPurchaseOrders()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newPurchaseOrders := []models.PurchaseOrder{}
+	for i := range purchaseOrders {
+		if purchaseOrders[i].ID == id {
+			isFound = true
+			continue
+		}
+		newPurchaseOrders = append(newPurchaseOrders, purchaseOrders[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newPurchaseOrders)
+}
+
+func (r *jsonPurchaseOrderRepository) save(purchaseOrders []models.PurchaseOrder) error {
+	filestring, err := json.MarshalIndent(purchaseOrders, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type RecipeVersionRepository interface {
+	GetRecipeVersions() ([]models.RecipeVersion, error)
+	GetRecipeVersionID(id string) (models.RecipeVersion, error)
+	CreateRecipeVersion(newRecipeVersion models.RecipeVersion) error
+	UpdateRecipeVersion(id string, newRecipeVersion models.RecipeVersion) error
+	DeleteRecipeVersion(id string) error
+}
+
+type jsonRecipeVersionRepository struct {
+	filepath string
+}
+
+func NewRecipeVersionRepository(filepath string) RecipeVersionRepository {
+	return &jsonRecipeVersionRepository{filepath: filepath}
+}
+
+func (r *jsonRecipeVersionRepository) GetRecipeVersions() ([]models.RecipeVersion, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.RecipeVersion{}, myerrors.ErrFailOpenJson
+	}
+
+	var recipeVersions []models.RecipeVersion
+	if err := json.Unmarshal(byteValue, &recipeVersions); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.RecipeVersion{}, myerrors.ErrFailUnmarshal
+	}
+
+	return recipeVersions, nil
+}
+
+func (r *jsonRecipeVersionRepository) GetRecipeVersionID(id string) (models.RecipeVersion, error) {
+	recipeVersions, err := r.GetRecipeVersions()
+	if err != nil {
+		return models.RecipeVersion{}, err
+	}
+
+	for _, recipeVersion := range recipeVersions {
+		if recipeVersion.ID == id {
+			return recipeVersion, nil
+		}
+	}
+
+	return models.RecipeVersion{}, myerrors.ErrNotFound
+}
+
+func (r *jsonRecipeVersionRepository) CreateRecipeVersion(newRecipeVersion models.RecipeVersion) error {
+	recipeVersions, err := r.GetRecipeVersions()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(recipeVersions, newRecipeVersion))
+}
+
+func (r *jsonRecipeVersionRepository) UpdateRecipeVersion(id string, newRecipeVersion models.RecipeVersion) error {
+	recipeVersions, err := r.GetRecipeVersions()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range recipeVersions {
+		if recipeVersions[i].ID == id {
+			recipeVersions[i] = newRecipeVersion
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(recipeVersions)
+}
+
+func (r *jsonRecipeVersionRepository) DeleteRecipeVersion(id string) error {
+	recipeVersions, err := r.GetRecipeVersions()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newRecipeVersions := []models.RecipeVersion{}
+	for i := range recipeVersions {
+		if recipeVersions[i].ID == id {
+			isFound = true
+			continue
+		}
+		newRecipeVersions = append(newRecipeVersions, recipeVersions[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newRecipeVersions)
+}
+
+func (r *jsonRecipeVersionRepository) save(recipeVersions []models.RecipeVersion) error {
+	filestring, err := json.MarshalIndent(recipeVersions, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type StocktakeRepository interface {
+	GetStocktakes() ([]models.Stocktake, error)
+	GetStocktakeID(id string) (models.Stocktake, error)
+	CreateStocktake(newStocktake models.Stocktake) error
+	UpdateStocktake(id string, newStocktake models.Stocktake) error
+	DeleteStocktake(id string) error
+}
+
+type jsonStocktakeRepository struct {
+	filepath string
+}
+
+func NewStocktakeRepository(filepath string) StocktakeRepository {
+	return &jsonStocktakeRepository{filepath: filepath}
+}
+
+func (r *jsonStocktakeRepository) GetStocktakes() ([]models.Stocktake, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Stocktake{}, myerrors.ErrFailOpenJson
+	}
+
+	var stocktakes []models.Stocktake
+	if err := json.Unmarshal(byteValue, &stocktakes); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Stocktake{}, myerrors.ErrFailUnmarshal
+	}
+
+	return stocktakes, nil
+}
+
+func (r *jsonStocktakeRepository) GetStocktakeID(id string) (models.Stocktake, error) {
+	stocktakes, err := r.GetStocktakes()
+	if err != nil {
+		return models.Stocktake{}, err
+	}
+
+	for _, stocktake := range stocktakes {
+		if stocktake.ID == id {
+			return stocktake, nil
+		}
+	}
+
+	return models.Stocktake{}, myerrors.ErrNotFound
+}
+
+func (r *jsonStocktakeRepository) CreateStocktake(newStocktake models.Stocktake) error {
+	stocktakes, err := r.GetStocktakes()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(stocktakes, newStocktake))
+}
+
+func (r *jsonStocktakeRepository) UpdateStocktake(id string, newStocktake models.Stocktake) error {
+	stocktakes, err := r.GetStocktakes()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range stocktakes {
+		if stocktakes[i].ID == id {
+			stocktakes[i] = newStocktake
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(stocktakes)
+}
+
+func (r *jsonStocktakeRepository) DeleteStocktake(id string) error {
+	stocktakes, err := r.GetStocktakes()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newStocktakes := []models.Stocktake{}
+	for i := range stocktakes {
+		if stocktakes[i].ID == id {
+			isFound = true
+			continue
+		}
+		newStocktakes = append(newStocktakes, stocktakes[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newStocktakes)
+}
+
+func (r *jsonStocktakeRepository) save(stocktakes []models.Stocktake) error {
+	filestring, err := json.MarshalIndent(stocktakes, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type SupplierRepository interface {
+	GetSuppliers() ([]models.Supplier, error)
+	GetSupplierID(id string) (models.Supplier, error)
+	CreateSupplier(newSupplier models.Supplier) error
+	UpdateSupplier(id string, newSupplier models.Supplier) error
+	DeleteSupplier(id string) error
+}
+
+type jsonSupplierRepository struct {
+	filepath string
+}
+
+func NewSupplierRepository(filepath string) SupplierRepository {
+	return &jsonSupplierRepository{filepath: filepath}
+}
+
+func (r *jsonSupplierRepository) GetSuppliers() ([]models.Supplier, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Supplier{}, myerrors.ErrFailOpenJson
+	}
+
+	var suppliers []models.Supplier
+	if err := json.Unmarshal(byteValue, &suppliers); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Supplier{}, myerrors.ErrFailUnmarshal
+	}
+
+	return suppliers, nil
+}
+
+func (r *jsonSupplierRepository) GetSupplierID(id string) (models.Supplier, error) {
+	suppliers, err := r.GetSuppliers()
+	if err != nil {
+		return models.Supplier{}, err
+	}
+
+	for _, supplier := range suppliers {
+		if supplier.ID == id {
+			return supplier, nil
+		}
+	}
+
+	return models.Supplier{}, myerrors.ErrNotFound
+}
+
+func (r *jsonSupplierRepository) CreateSupplier(newSupplier models.Supplier) error {
+	suppliers, err := r.GetSuppliers()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(suppliers, newSupplier))
+}
+
+func (r *jsonSupplierRepository) UpdateSupplier(id string, newSupplier models.Supplier) error {
+	suppliers, err := r.GetSuppliers()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range suppliers {
+		if suppliers[i].ID == id {
+			suppliers[i] = newSupplier
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(suppliers)
+}
+
+func (r *jsonSupplierRepository) DeleteSupplier(id string) error {
+	suppliers, err := r.GetSuppliers()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newSuppliers := []models.Supplier{}
+	for i := range suppliers {
+		if suppliers[i].ID == id {
+			isFound = true
+			continue
+		}
+		newSuppliers = append(newSuppliers, suppliers[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newSuppliers)
+}
+
+func (r *jsonSupplierRepository) save(suppliers []models.Supplier) error {
+	filestring, err := json.MarshalIndent(suppliers, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
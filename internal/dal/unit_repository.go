@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type UnitRepository interface {
+	GetUnits() ([]models.Unit, error)
+	GetUnitID(id string) (models.Unit, error)
+	CreateUnit(newUnit models.Unit) error
+	UpdateUnit(id string, newUnit models.Unit) error
+	DeleteUnit(id string) error
+}
+
+type jsonUnitRepository struct {
+	filepath string
+}
+
+func NewUnitRepository(filepath string) UnitRepository {
+	return &jsonUnitRepository{filepath: filepath}
+}
+
+func (r *jsonUnitRepository) GetUnits() ([]models.Unit, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Unit{}, myerrors.ErrFailOpenJson
+	}
+
+	var units []models.Unit
+	if err := json.Unmarshal(byteValue, &units); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Unit{}, myerrors.ErrFailUnmarshal
+	}
+
+	return units, nil
+}
+
+func (r *jsonUnitRepository) GetUnitID(id string) (models.Unit, error) {
+	units, err := r.GetUnits()
+	if err != nil {
+		return models.Unit{}, err
+	}
+
+	for _, unit := range units {
+		if unit.Name == id {
+			return unit, nil
+		}
+	}
+
+	return models.Unit{}, myerrors.ErrNotFound
+}
+
+func (r *jsonUnitRepository) CreateUnit(newUnit models.Unit) error {
+	units, err := r.GetUnits()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(units, newUnit))
+}
+
+func (r *jsonUnitRepository) UpdateUnit(id string, newUnit models.Unit) error {
+	units, err := r.GetUnits()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range units {
+		if units[i].Name == id {
+			units[i] = newUnit
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(units)
+}
+
+func (r *jsonUnitRepository) DeleteUnit(id string) error {
+	units, err := r.GetUnits()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newUnits := []models.Unit{}
+	for i := range units {
+		if units[i].Name == id {
+			isFound = true
+			continue
+		}
+		newUnits = append(newUnits, units[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newUnits)
+}
+
+func (r *jsonUnitRepository) save(units []models.Unit) error {
+	filestring, err := json.MarshalIndent(units, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
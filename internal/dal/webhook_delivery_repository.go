@@ -0,0 +1,104 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type WebhookDeliveryRepository interface {
+	GetWebhookDeliveries() ([]models.WebhookDelivery, error)
+	GetWebhookDeliveryID(id string) (models.WebhookDelivery, error)
+	CreateWebhookDelivery(newWebhookDelivery models.WebhookDelivery) error
+	UpdateWebhookDelivery(id string, newWebhookDelivery models.WebhookDelivery) error
+}
+
+type jsonWebhookDeliveryRepository struct {
+	filepath string
+}
+
+func NewWebhookDeliveryRepository(filepath string) WebhookDeliveryRepository {
+	return &jsonWebhookDeliveryRepository{filepath: filepath}
+}
+
+func (r *jsonWebhookDeliveryRepository) GetWebhookDeliveries() ([]models.WebhookDelivery, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.WebhookDelivery{}, myerrors.ErrFailOpenJson
+	}
+
+	var webhookDeliveries []models.WebhookDelivery
+	if err := json.Unmarshal(byteValue, &webhookDeliveries); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.WebhookDelivery{}, myerrors.ErrFailUnmarshal
+	}
+
+	return webhookDeliveries, nil
+}
+
+func (r *jsonWebhookDeliveryRepository) GetWebhookDeliveryID(id string) (models.WebhookDelivery, error) {
+	webhookDeliveries, err := r.GetWebhookDeliveries()
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	for _, webhookDelivery := range webhookDeliveries {
+		if webhookDelivery.ID == id {
+			return webhookDelivery, nil
+		}
+	}
+
+	return models.WebhookDelivery{}, myerrors.ErrNotFound
+}
+
+func (r *jsonWebhookDeliveryRepository) CreateWebhookDelivery(newWebhookDelivery models.WebhookDelivery) error {
+	webhookDeliveries, err := r.GetWebhookDeliveries()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(webhookDeliveries, newWebhookDelivery))
+}
+
+func (r *jsonWebhookDeliveryRepository) UpdateWebhookDelivery(id string, newWebhookDelivery models.WebhookDelivery) error {
+	webhookDeliveries, err := r.GetWebhookDeliveries()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range webhookDeliveries {
+		if webhookDeliveries[i].ID == id {
+			webhookDeliveries[i] = newWebhookDelivery
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(webhookDeliveries)
+}
+
+func (r *jsonWebhookDeliveryRepository) save(webhookDeliveries []models.WebhookDelivery) error {
+	filestring, err := json.MarshalIndent(webhookDeliveries, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
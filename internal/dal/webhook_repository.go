@@ -0,0 +1,129 @@
+package dal
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/utils"
+	"hot-coffee/models"
+	"log/slog"
+	"os"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type WebhookRepository interface {
+	GetWebhooks() ([]models.Webhook, error)
+	GetWebhookID(id string) (models.Webhook, error)
+	CreateWebhook(newWebhook models.Webhook) error
+	UpdateWebhook(id string, newWebhook models.Webhook) error
+	DeleteWebhook(id string) error
+}
+
+type jsonWebhookRepository struct {
+	filepath string
+}
+
+func NewWebhookRepository(filepath string) WebhookRepository {
+	return &jsonWebhookRepository{filepath: filepath}
+}
+
+func (r *jsonWebhookRepository) GetWebhooks() ([]models.Webhook, error) {
+	byteValue, err := utils.ReadFile(r.filepath)
+	if err != nil {
+		slog.Error("Failed to open", "error", err, "file path", r.filepath)
+		return []models.Webhook{}, myerrors.ErrFailOpenJson
+	}
+
+	var webhooks []models.Webhook
+	if err := json.Unmarshal(byteValue, &webhooks); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return []models.Webhook{}, myerrors.ErrFailUnmarshal
+	}
+
+	return webhooks, nil
+}
+
+func (r *jsonWebhookRepository) GetWebhookID(id string) (models.Webhook, error) {
+	webhooks, err := r.GetWebhooks()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+
+	return models.Webhook{}, myerrors.ErrNotFound
+}
+
+func (r *jsonWebhookRepository) CreateWebhook(newWebhook models.Webhook) error {
+	webhooks, err := r.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	return r.save(append(webhooks, newWebhook))
+}
+
+func (r *jsonWebhookRepository) UpdateWebhook(id string, newWebhook models.Webhook) error {
+	webhooks, err := r.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	for i := range webhooks {
+		if webhooks[i].ID == id {
+			webhooks[i] = newWebhook
+			isFound = true
+		}
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(webhooks)
+}
+
+func (r *jsonWebhookRepository) DeleteWebhook(id string) error {
+	webhooks, err := r.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	var isFound bool
+	newWebhooks := []models.Webhook{}
+	for i := range webhooks {
+		if webhooks[i].ID == id {
+			isFound = true
+			continue
+		}
+		newWebhooks = append(newWebhooks, webhooks[i])
+	}
+
+	if !isFound {
+		slog.Error("Failed to find", "error", myerrors.ErrNotFound)
+		return myerrors.ErrNotFound
+	}
+
+	return r.save(newWebhooks)
+}
+
+func (r *jsonWebhookRepository) save(webhooks []models.Webhook) error {
+	filestring, err := json.MarshalIndent(webhooks, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return myerrors.ErrFailMarshal
+	}
+
+	if err := os.WriteFile(*config.Dir+"/"+r.filepath, filestring, os.ModePerm); err != nil {
+		slog.Error("Failed to write file", "error", err, "file path", r.filepath)
+		return myerrors.ErrFailWrite
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"hot-coffee/internal/service"
 	"hot-coffee/internal/utils/response"
 	"net/http"
+	"strconv"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
@@ -11,6 +13,8 @@ import (
 type AggregationsHandler interface {
 	HandleGetSales(w http.ResponseWriter, r *http.Request)
 	HandleGetPopItems(w http.ResponseWriter, r *http.Request)
+	HandleGetConsumption(w http.ResponseWriter, r *http.Request)
+	HandleGetComponentSales(w http.ResponseWriter, r *http.Request)
 }
 
 type aggregationsHandler struct {
@@ -29,8 +33,7 @@ func (s *aggregationsHandler) HandleGetSales(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Get a list of popular menu items.
@@ -38,11 +41,89 @@ func (s *aggregationsHandler) HandleGetPopItems(w http.ResponseWriter, r *http.R
 	byteValue, err := s.service.ServiceGetPopular()
 	if err == myerrors.ErrNoItems {
 		response.SendError(w, http.StatusNotFound, "Failed to retrieve popular items", err)
+		return
 	} else if err != nil {
 		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve popular items", nil)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
+}
+
+// Get theoretical vs actual ingredient consumption over a period.
+func (s *aggregationsHandler) HandleGetConsumption(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r, 30*24*time.Hour)
+	if err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to retrieve consumption report", err)
+		return
+	}
+
+	threshold := 5.0
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 {
+			response.SendError(w, http.StatusBadRequest, "Failed to retrieve consumption report", myerrors.ErrInvalidThreshold)
+			return
+		}
+	}
+
+	byteValue, err := s.service.ServiceGetConsumption(from, to, threshold)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve consumption report", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Get sales per menu item with bundle sales attributed to their components.
+func (s *aggregationsHandler) HandleGetComponentSales(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r, 30*24*time.Hour)
+	if err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to retrieve component sales", err)
+		return
+	}
+
+	byteValue, err := s.service.ServiceGetComponentSales(from, to)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve component sales", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// parsePeriod reads ?from= and ?to= (RFC3339 or YYYY-MM-DD), by default the period
+// ends now and starts defaultLength earlier.
+func parsePeriod(r *http.Request, defaultLength time.Duration) (time.Time, time.Time, error) {
+	to := time.Now()
+	if value := r.URL.Query().Get("to"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, myerrors.ErrInvalidPeriod
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultLength)
+	if value := r.URL.Query().Get("from"); value != "" {
+		t, err := parseTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, myerrors.ErrInvalidPeriod
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, myerrors.ErrInvalidPeriod
+	}
+
+	return from, to, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
 }
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type AlertHandler interface {
+	HandleGetAlerts(w http.ResponseWriter, r *http.Request)
+	HandleAcknowledgeAlert(w http.ResponseWriter, r *http.Request)
+	HandleResolveAlert(w http.ResponseWriter, r *http.Request)
+}
+
+type alertHandler struct {
+	service service.AlertService
+}
+
+func NewAlertHandler(service service.AlertService) AlertHandler {
+	return &alertHandler{service: service}
+}
+
+// Retrieve low-stock alerts.
+func (s *alertHandler) HandleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetAlerts(r.URL.Query().Get("status"))
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve alerts", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Acknowledge an open alert.
+func (s *alertHandler) HandleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceAcknowledgeAlert(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to acknowledge alert", err)
+		return
+	case myerrors.ErrAlertNotOpen:
+		response.SendError(w, http.StatusConflict, "Failed to acknowledge alert", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to acknowledge alert", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "alert succesfuly acknowledged")
+}
+
+// Resolve an alert.
+func (s *alertHandler) HandleResolveAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceResolveAlert(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to resolve alert", err)
+		return
+	case myerrors.ErrAlertResolved:
+		response.SendError(w, http.StatusConflict, "Failed to resolve alert", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to resolve alert", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "alert succesfuly resolved")
+}
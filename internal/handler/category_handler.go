@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CategoryHandler interface {
+	HandleGetCategories(w http.ResponseWriter, r *http.Request)
+	HandleGetCategoryID(w http.ResponseWriter, r *http.Request)
+	HandlePostCategory(w http.ResponseWriter, r *http.Request)
+	HandlePutCategoryID(w http.ResponseWriter, r *http.Request)
+	HandleDeleteCategory(w http.ResponseWriter, r *http.Request)
+}
+
+type categoryHandler struct {
+	service service.CategoryService
+}
+
+func NewCategoryHandler(service service.CategoryService) CategoryHandler {
+	return &categoryHandler{service: service}
+}
+
+// Retrieve all categories in display order.
+func (s *categoryHandler) HandleGetCategories(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetCategories()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve categories", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific category.
+func (s *categoryHandler) HandleGetCategoryID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetCategoryID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve category", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve category", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a new category.
+func (s *categoryHandler) HandlePostCategory(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	categoryByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create category", nil)
+		return
+	}
+
+	err = s.service.ServiceCreateCategory(categoryByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrNameRequired,
+		myerrors.ErrInvalidStation,
+		myerrors.ErrInvalidSchedule,
+		myerrors.ErrIDExist:
+		response.SendError(w, http.StatusBadRequest, "Failed to create category", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create category", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "category succesfuly created")
+}
+
+// Update a category.
+func (s *categoryHandler) HandlePutCategoryID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	categoryByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update category", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdateCategory(id, categoryByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrNameRequired,
+		myerrors.ErrInvalidStation,
+		myerrors.ErrInvalidSchedule:
+		response.SendError(w, http.StatusBadRequest, "Failed to update category", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update category", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update category", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "category succesfuly updated")
+}
+
+// Delete a category.
+func (s *categoryHandler) HandleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceDeleteCategory(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete category", err)
+		return
+	case myerrors.ErrCategoryInUse:
+		response.SendError(w, http.StatusConflict, "Failed to delete category", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete category", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "category succesfuly deleted")
+}
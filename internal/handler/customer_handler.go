@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CustomerHandler interface {
+	HandleGetCustomers(w http.ResponseWriter, r *http.Request)
+	HandleGetCustomerID(w http.ResponseWriter, r *http.Request)
+	HandlePostCustomer(w http.ResponseWriter, r *http.Request)
+	HandlePutCustomerID(w http.ResponseWriter, r *http.Request)
+	HandleDeleteCustomer(w http.ResponseWriter, r *http.Request)
+	HandleGetCustomerOrders(w http.ResponseWriter, r *http.Request)
+}
+
+type customerHandler struct {
+	service service.CustomerService
+}
+
+func NewCustomerHandler(service service.CustomerService) CustomerHandler {
+	return &customerHandler{service: service}
+}
+
+// Retrieve all customers.
+func (s *customerHandler) HandleGetCustomers(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetCustomers()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve customers", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific customer.
+func (s *customerHandler) HandleGetCustomerID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetCustomerID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve customer", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve customer", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a new customer, the response carries the generated customer_id.
+func (s *customerHandler) HandlePostCustomer(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	customerByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create customer", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceCreateCustomer(customerByte)
+	switch err {
+	case myerrors.ErrNameRequired,
+		myerrors.ErrInvalidEmail,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to create customer", err)
+		return
+	case myerrors.ErrDuplicateCustomer:
+		response.SendError(w, http.StatusConflict, "Failed to create customer", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create customer", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Update a customer.
+func (s *customerHandler) HandlePutCustomerID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	customerByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update customer", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdateCustomer(id, customerByte)
+	switch err {
+	case myerrors.ErrNameRequired,
+		myerrors.ErrInvalidEmail,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to update customer", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update customer", err)
+		return
+	case myerrors.ErrDuplicateCustomer:
+		response.SendError(w, http.StatusConflict, "Failed to update customer", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update customer", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "customer succesfuly updated")
+}
+
+// Delete a customer that has no orders.
+func (s *customerHandler) HandleDeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceDeleteCustomer(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete customer", err)
+		return
+	case myerrors.ErrHasDependents:
+		sendConflict(w, byteValue)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete customer", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "customer succesfuly deleted")
+}
+
+// Retrieve the orders of a customer with lifetime spend and visit count.
+func (s *customerHandler) HandleGetCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetCustomerOrders(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve customer orders", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve customer orders", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
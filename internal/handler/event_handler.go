@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// How often a comment is sent on an idle stream so proxies keep it open.
+const eventHeartbeat = 30 * time.Second
+
+type EventHandler interface {
+	HandleGetEvents(w http.ResponseWriter, r *http.Request)
+}
+
+type eventHandler struct {
+	events service.EventBus
+}
+
+func NewEventHandler(events service.EventBus) EventHandler {
+	return &eventHandler{events: events}
+}
+
+// Stream events as Server-Sent Events, ?topics=orders,menu picks the topics. A client
+// resuming with a Last-Event-ID header, or ?last_event_id=, first gets the events it
+// missed; a reset event tells it some of them are gone and it should reload.
+func (s *eventHandler) HandleGetEvents(w http.ResponseWriter, r *http.Request) {
+	var topics []string
+	if value := r.URL.Query().Get("topics"); value != "" {
+		for _, topic := range strings.Split(value, ",") {
+			topic = strings.TrimSpace(topic)
+			if !slices.Contains(models.Topics, topic) {
+				response.SendError(w, http.StatusBadRequest, "Failed to stream events", myerrors.ErrUnknownTopic)
+				return
+			}
+			topics = append(topics, topic)
+		}
+	}
+
+	var lastID int64
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" {
+		var err error
+		if lastID, err = strconv.ParseInt(lastEventID, 10, 64); err != nil {
+			response.SendError(w, http.StatusBadRequest, "Failed to stream events", myerrors.ErrInvalidEventID)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.SendError(w, http.StatusInternalServerError, "Failed to stream events", nil)
+		return
+	}
+
+	missed, complete, events, cancel := s.events.Subscribe(topics, lastID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Too far behind, the client reconnects with Last-Event-ID and catches up.
+				return
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event models.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to marshal event", "error", err, "id", event.ID)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
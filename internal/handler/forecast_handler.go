@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"net/http"
+	"strconv"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type ForecastHandler interface {
+	HandleGetForecast(w http.ResponseWriter, r *http.Request)
+}
+
+type forecastHandler struct {
+	service service.ForecastService
+}
+
+func NewForecastHandler(service service.ForecastService) ForecastHandler {
+	return &forecastHandler{service: service}
+}
+
+// Retrieve the stock forecast. ?days= is the lookback period (default 28), ?lead_time=
+// the supplier lead time (default 3) and ?target_cover= the days a reorder should last (default 14).
+func (s *forecastHandler) HandleGetForecast(w http.ResponseWriter, r *http.Request) {
+	days := 28
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			response.SendError(w, http.StatusBadRequest, "Failed to retrieve forecast", myerrors.ErrInvalidDays)
+			return
+		}
+	}
+
+	leadTime, ok := floatParam(r, "lead_time", 3)
+	if !ok {
+		response.SendError(w, http.StatusBadRequest, "Failed to retrieve forecast", myerrors.ErrInvalidLeadTime)
+		return
+	}
+
+	targetCover, ok := floatParam(r, "target_cover", 14)
+	if !ok {
+		response.SendError(w, http.StatusBadRequest, "Failed to retrieve forecast", myerrors.ErrInvalidTargetCover)
+		return
+	}
+
+	byteValue, err := s.service.ServiceGetForecast(days, leadTime, targetCover)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve forecast", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// floatParam reads a non-negative number from the query string.
+func floatParam(r *http.Request, name string, defaultValue float64) (float64, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, true
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, false
+	}
+	return number, true
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type GiftCardHandler interface {
+	HandleGetGiftCards(w http.ResponseWriter, r *http.Request)
+	HandleGetGiftCard(w http.ResponseWriter, r *http.Request)
+	HandleIssueGiftCard(w http.ResponseWriter, r *http.Request)
+	HandleTopUpGiftCard(w http.ResponseWriter, r *http.Request)
+	HandleRedeemGiftCard(w http.ResponseWriter, r *http.Request)
+	HandleVoidGiftCard(w http.ResponseWriter, r *http.Request)
+	HandlePayOrder(w http.ResponseWriter, r *http.Request)
+}
+
+type giftCardHandler struct {
+	service service.GiftCardService
+}
+
+func NewGiftCardHandler(service service.GiftCardService) GiftCardHandler {
+	return &giftCardHandler{service: service}
+}
+
+// Retrieve all gift cards.
+func (s *giftCardHandler) HandleGetGiftCards(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetGiftCards()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve gift cards", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve the balance and transactions of a gift card.
+func (s *giftCardHandler) HandleGetGiftCard(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	byteValue, err := s.service.ServiceGetGiftCard(code)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve gift card", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve gift card", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Issue a gift card, the response carries the generated code.
+func (s *giftCardHandler) HandleIssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	operationByte, ok := readGiftCardOperation(w, r, "Failed to issue gift card")
+	if !ok {
+		return
+	}
+
+	byteValue, err := s.service.ServiceIssueGiftCard(operationByte)
+	switch err {
+	case myerrors.ErrInvalidAmount,
+		myerrors.ErrUnknownCustomer,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to issue gift card", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to issue gift card", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Put money on a gift card.
+func (s *giftCardHandler) HandleTopUpGiftCard(w http.ResponseWriter, r *http.Request) {
+	operationByte, ok := readGiftCardOperation(w, r, "Failed to top up gift card")
+	if !ok {
+		return
+	}
+
+	byteValue, err := s.service.ServiceTopUpGiftCard(r.PathValue("code"), operationByte)
+	if sendGiftCardError(w, err, "Failed to top up gift card") {
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Take money off a gift card.
+func (s *giftCardHandler) HandleRedeemGiftCard(w http.ResponseWriter, r *http.Request) {
+	operationByte, ok := readGiftCardOperation(w, r, "Failed to redeem gift card")
+	if !ok {
+		return
+	}
+
+	byteValue, err := s.service.ServiceRedeemGiftCard(r.PathValue("code"), operationByte)
+	if sendGiftCardError(w, err, "Failed to redeem gift card") {
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Void a gift card.
+func (s *giftCardHandler) HandleVoidGiftCard(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceVoidGiftCard(r.PathValue("code"))
+	if sendGiftCardError(w, err, "Failed to void gift card") {
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Pay all or part of an order with a gift card.
+func (s *giftCardHandler) HandlePayOrder(w http.ResponseWriter, r *http.Request) {
+	operationByte, ok := readGiftCardOperation(w, r, "Failed to pay order")
+	if !ok {
+		return
+	}
+
+	byteValue, err := s.service.ServicePayOrder(r.PathValue("id"), operationByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrOverpayment:
+		response.SendError(w, http.StatusBadRequest, "Failed to pay order", err)
+		return
+	case myerrors.ErrArchived,
+		myerrors.ErrOrderRefunded,
+		myerrors.ErrOrderPaid:
+		response.SendError(w, http.StatusConflict, "Failed to pay order", err)
+		return
+	}
+	if sendGiftCardError(w, err, "Failed to pay order") {
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+func readGiftCardOperation(w http.ResponseWriter, r *http.Request, message string) ([]byte, bool) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return nil, false
+	}
+
+	operationByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, message, nil)
+		return nil, false
+	}
+	return operationByte, true
+}
+
+// sendGiftCardError maps the errors of operations on a card, it tells whether one was sent.
+func sendGiftCardError(w http.ResponseWriter, err error, message string) bool {
+	switch err {
+	case nil:
+		return false
+	case myerrors.ErrInvalidAmount,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, message, err)
+	case myerrors.ErrGiftCardVoid,
+		myerrors.ErrInsufficientFunds:
+		response.SendError(w, http.StatusConflict, message, err)
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, message, err)
+	default:
+		response.SendError(w, http.StatusInternalServerError, message, nil)
+	}
+	return true
+}
@@ -16,6 +16,10 @@ type InventoryHandler interface {
 	HandlePostInventory(w http.ResponseWriter, r *http.Request)
 	HandlePutInventoryID(w http.ResponseWriter, r *http.Request)
 	HandleDeleteInventory(w http.ResponseWriter, r *http.Request)
+	HandleRestoreInventory(w http.ResponseWriter, r *http.Request)
+	HandleImportInventory(w http.ResponseWriter, r *http.Request)
+	HandleAdjustInventory(w http.ResponseWriter, r *http.Request)
+	HandleGetMovements(w http.ResponseWriter, r *http.Request)
 }
 
 type inventoryHandler struct {
@@ -26,16 +30,15 @@ func NewInventoryHandler(service service.InventoryService) InventoryHandler {
 	return &inventoryHandler{service: service}
 }
 
-// Retrieve all inventory items.
+// Retrieve all inventory items, ?include_archived=true lists archived items too.
 func (s *inventoryHandler) HandleGetInventory(w http.ResponseWriter, r *http.Request) {
-	byteValue, err := s.service.ServiceGetInventory()
+	byteValue, err := s.service.ServiceGetInventory(r.URL.Query().Get("include_archived") == "true")
 	if err != nil {
 		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve inventory", nil)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Retrieve a specific inventory item.
@@ -51,8 +54,7 @@ func (s *inventoryHandler) HandleGetInventoryID(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Add a new inventory item.
@@ -75,6 +77,9 @@ func (s *inventoryHandler) HandlePostInventory(w http.ResponseWriter, r *http.Re
 		myerrors.ErrNameRequired,
 		myerrors.ErrInvalidQuantity,
 		myerrors.ErrUnitRequired,
+		myerrors.ErrInvalidMinQuantity,
+		myerrors.ErrUnknownAllergen,
+		myerrors.ErrInvalidNutrition,
 		myerrors.ErrIDExist:
 		response.SendError(w, http.StatusBadRequest, "Failed to create inventory", err)
 		return
@@ -108,9 +113,16 @@ func (s *inventoryHandler) HandlePutInventoryID(w http.ResponseWriter, r *http.R
 	case myerrors.ErrIdRequired,
 		myerrors.ErrNameRequired,
 		myerrors.ErrInvalidQuantity,
-		myerrors.ErrUnitRequired:
+		myerrors.ErrUnitRequired,
+		myerrors.ErrInvalidMinQuantity,
+		myerrors.ErrUnknownAllergen,
+		myerrors.ErrInvalidNutrition,
+		myerrors.ErrUnitMismatch:
 		response.SendError(w, http.StatusBadRequest, "Failed to update inventory", err)
 		return
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to update inventory", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to update inventory", err)
 		return
@@ -124,14 +136,20 @@ func (s *inventoryHandler) HandlePutInventoryID(w http.ResponseWriter, r *http.R
 	response.SendMessage(w, http.StatusCreated, "inventory item succesfuly updated")
 }
 
-// Delete an inventory item.
+// Delete an inventory item, ?cascade=true first takes it out of recipes and supplier catalogs.
 func (s *inventoryHandler) HandleDeleteInventory(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	err := s.service.ServiceDeleteInventory(id)
+	byteValue, err := s.service.ServiceDeleteInventory(id, r.URL.Query().Get("cascade") == "true")
 	switch err {
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to delete inventory", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to delete inventory", err)
 		return
+	case myerrors.ErrHasDependents:
+		sendConflict(w, byteValue)
+		return
 	default:
 		if err != nil {
 			response.SendError(w, http.StatusInternalServerError, "Failed to delete inventory", nil)
@@ -141,3 +159,94 @@ func (s *inventoryHandler) HandleDeleteInventory(w http.ResponseWriter, r *http.
 
 	response.SendMessage(w, http.StatusAccepted, "inventory item succesfuly deleted")
 }
+
+// Restore an archived inventory item.
+func (s *inventoryHandler) HandleRestoreInventory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceRestoreInventory(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to restore inventory", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to restore inventory", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "inventory item succesfuly restored")
+}
+
+// Import inventory items from a JSON array or CSV.
+func (s *inventoryHandler) HandleImportInventory(w http.ResponseWriter, r *http.Request) {
+	isCSV, dryRun, upsert, err := importOptions(r)
+	if err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to import inventory", err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to import inventory", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceImportInventory(body, isCSV, dryRun, upsert)
+	sendImportResult(w, "Failed to import inventory", byteValue, err)
+}
+
+// Change the stock of an inventory item by a relative amount.
+func (s *inventoryHandler) HandleAdjustInventory(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	adjustmentByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to adjust inventory", nil)
+		return
+	}
+
+	err = s.service.ServiceAdjustInventory(id, adjustmentByte)
+	switch err {
+	case myerrors.ErrInvalidDelta,
+		myerrors.ErrInvalidReason,
+		myerrors.ErrNegativeStock:
+		response.SendError(w, http.StatusBadRequest, "Failed to adjust inventory", err)
+		return
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to adjust inventory", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to adjust inventory", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to adjust inventory", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "inventory item succesfuly adjusted")
+}
+
+// Retrieve the stock movements of an inventory item.
+func (s *inventoryHandler) HandleGetMovements(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetMovements(id, r.URL.Query().Get("reason"))
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve movements", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve movements", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+	"strconv"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LotHandler interface {
+	HandleGetLots(w http.ResponseWriter, r *http.Request)
+	HandlePostLot(w http.ResponseWriter, r *http.Request)
+	HandleGetExpiringLots(w http.ResponseWriter, r *http.Request)
+}
+
+type lotHandler struct {
+	service service.LotService
+}
+
+func NewLotHandler(service service.LotService) LotHandler {
+	return &lotHandler{service: service}
+}
+
+// Retrieve the lots of an inventory item.
+func (s *lotHandler) HandleGetLots(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetLots(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve lots", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve lots", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Receive a new lot of an inventory item.
+func (s *lotHandler) HandlePostLot(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	lotByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create lot", nil)
+		return
+	}
+
+	err = s.service.ServiceCreateLot(id, lotByte)
+	switch err {
+	case myerrors.ErrInvalidQuantity,
+		myerrors.ErrInvalidExpiry:
+		response.SendError(w, http.StatusBadRequest, "Failed to create lot", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to create lot", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create lot", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "lot succesfuly received")
+}
+
+// Retrieve lots expiring within ?days= days (default 3).
+func (s *lotHandler) HandleGetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	days := 3
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 0 {
+			response.SendError(w, http.StatusBadRequest, "Failed to retrieve expiring lots", myerrors.ErrInvalidDays)
+			return
+		}
+	}
+
+	byteValue, err := s.service.ServiceGetExpiringLots(days)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve expiring lots", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LoyaltyHandler interface {
+	HandleGetRules(w http.ResponseWriter, r *http.Request)
+	HandleGetRuleID(w http.ResponseWriter, r *http.Request)
+	HandlePostRule(w http.ResponseWriter, r *http.Request)
+	HandlePutRuleID(w http.ResponseWriter, r *http.Request)
+	HandleDeleteRule(w http.ResponseWriter, r *http.Request)
+	HandleGetAccount(w http.ResponseWriter, r *http.Request)
+}
+
+type loyaltyHandler struct {
+	service service.LoyaltyService
+}
+
+func NewLoyaltyHandler(service service.LoyaltyService) LoyaltyHandler {
+	return &loyaltyHandler{service: service}
+}
+
+// Retrieve all loyalty rules.
+func (s *loyaltyHandler) HandleGetRules(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetRules()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve loyalty rules", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific loyalty rule.
+func (s *loyaltyHandler) HandleGetRuleID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetRuleID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve loyalty rule", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve loyalty rule", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a new loyalty rule, the response carries the generated rule_id.
+func (s *loyaltyHandler) HandlePostRule(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	ruleByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create loyalty rule", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceCreateRule(ruleByte)
+	switch err {
+	case myerrors.ErrNameRequired,
+		myerrors.ErrInvalidRuleType,
+		myerrors.ErrInvalidPointsRate,
+		myerrors.ErrCategoryRequired,
+		myerrors.ErrUnknownCategory,
+		myerrors.ErrInvalidRewardCost,
+		myerrors.ErrInvalidRewardValue,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to create loyalty rule", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create loyalty rule", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Update a loyalty rule.
+func (s *loyaltyHandler) HandlePutRuleID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	ruleByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update loyalty rule", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdateRule(id, ruleByte)
+	switch err {
+	case myerrors.ErrNameRequired,
+		myerrors.ErrInvalidRuleType,
+		myerrors.ErrInvalidPointsRate,
+		myerrors.ErrCategoryRequired,
+		myerrors.ErrUnknownCategory,
+		myerrors.ErrInvalidRewardCost,
+		myerrors.ErrInvalidRewardValue,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to update loyalty rule", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update loyalty rule", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update loyalty rule", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "loyalty rule succesfuly updated")
+}
+
+// Delete a loyalty rule without transactions.
+func (s *loyaltyHandler) HandleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceDeleteRule(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete loyalty rule", err)
+		return
+	case myerrors.ErrRuleInUse:
+		response.SendError(w, http.StatusConflict, "Failed to delete loyalty rule", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete loyalty rule", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "loyalty rule succesfuly deleted")
+}
+
+// Retrieve the loyalty balances and history of a customer.
+func (s *loyaltyHandler) HandleGetAccount(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetAccount(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve loyalty account", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve loyalty account", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
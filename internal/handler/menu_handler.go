@@ -6,6 +6,7 @@ import (
 	"hot-coffee/internal/utils/validation"
 	"io"
 	"net/http"
+	"strings"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
@@ -16,6 +17,9 @@ type MenuHandler interface {
 	HandlePostMenu(w http.ResponseWriter, r *http.Request)
 	HandlePutMenuID(w http.ResponseWriter, r *http.Request)
 	HandleDeleteMenuID(w http.ResponseWriter, r *http.Request)
+	HandleRestoreMenuID(w http.ResponseWriter, r *http.Request)
+	HandleImportMenu(w http.ResponseWriter, r *http.Request)
+	HandleGetRecipeVersions(w http.ResponseWriter, r *http.Request)
 }
 
 type menuHandler struct {
@@ -26,16 +30,40 @@ func NewMenuHandler(service service.MenuService) MenuHandler {
 	return &menuHandler{service: service}
 }
 
-// Retrieve all menu items.
+// Retrieve all menu items, ?available=true leaves out what cannot be made,
+// ?group_by=category groups the items by category, ?at= lists only what can be
+// ordered at that time, ?exclude_allergens=nuts,gluten leaves out items containing them
+// and ?include_archived=true lists archived items too.
 func (s *menuHandler) HandleGetMenu(w http.ResponseWriter, r *http.Request) {
-	byteValue, err := s.service.ServiceGetMenu()
+	filter := service.MenuFilter{
+		OnlyAvailable:   r.URL.Query().Get("available") == "true",
+		GroupByCategory: r.URL.Query().Get("group_by") == "category",
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+	}
+	if value := r.URL.Query().Get("at"); value != "" {
+		at, err := parseTime(value)
+		if err != nil {
+			response.SendError(w, http.StatusBadRequest, "Failed to retrieve menu", myerrors.ErrInvalidTimestamp)
+			return
+		}
+		filter.At = at
+	}
+	if value := r.URL.Query().Get("exclude_allergens"); value != "" {
+		for _, allergen := range strings.Split(value, ",") {
+			filter.ExcludeAllergens = append(filter.ExcludeAllergens, strings.ToLower(strings.TrimSpace(allergen)))
+		}
+		if err := validation.CheckAllergens(filter.ExcludeAllergens); err != nil {
+			response.SendError(w, http.StatusBadRequest, "Failed to retrieve menu", err)
+			return
+		}
+	}
+	byteValue, err := s.service.ServiceGetMenu(filter)
 	if err != nil {
 		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve menu", nil)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Retrieve a specific menu item.
@@ -51,11 +79,26 @@ func (s *menuHandler) HandleGetMenuID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Add a new menu item.
+// Retrieve the recipe history of a menu item with the changes between versions.
+func (s *menuHandler) HandleGetRecipeVersions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetRecipeVersions(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve recipe versions", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve recipe versions", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
 func (s *menuHandler) HandlePostMenu(w http.ResponseWriter, r *http.Request) {
 	contentType := r.Header.Get("Content-Type")
 	if !validation.IsJSON(contentType) {
@@ -77,6 +120,15 @@ func (s *menuHandler) HandlePostMenu(w http.ResponseWriter, r *http.Request) {
 		myerrors.ErrDescriptionRequired,
 		myerrors.ErrPriceRequired,
 		myerrors.ErrIngredientsRequired,
+		myerrors.ErrUnitMismatch,
+		myerrors.ErrUnknownIngredient,
+		myerrors.ErrUnknownCategory,
+		myerrors.ErrInvalidSchedule,
+		myerrors.ErrInvalidStation,
+		myerrors.ErrSlotRequired,
+		myerrors.ErrOptionsRequired,
+		myerrors.ErrInvalidComponent,
+		myerrors.ErrDuplicateID,
 		myerrors.ErrIDExist:
 		response.SendError(w, http.StatusBadRequest, "Failed to create menu", err)
 		return
@@ -114,9 +166,21 @@ func (s *menuHandler) HandlePutMenuID(w http.ResponseWriter, r *http.Request) {
 		myerrors.ErrInvalidQuantity,
 		myerrors.ErrDescriptionRequired,
 		myerrors.ErrPriceRequired,
-		myerrors.ErrIngredientsRequired:
+		myerrors.ErrIngredientsRequired,
+		myerrors.ErrUnitMismatch,
+		myerrors.ErrUnknownIngredient,
+		myerrors.ErrUnknownCategory,
+		myerrors.ErrInvalidSchedule,
+		myerrors.ErrInvalidStation,
+		myerrors.ErrSlotRequired,
+		myerrors.ErrOptionsRequired,
+		myerrors.ErrInvalidComponent,
+		myerrors.ErrDuplicateID:
 		response.SendError(w, http.StatusBadRequest, "Failed to update an menu", err)
 		return
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to update an menu", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to update an menu", err)
 		return
@@ -129,14 +193,21 @@ func (s *menuHandler) HandlePutMenuID(w http.ResponseWriter, r *http.Request) {
 	response.SendMessage(w, http.StatusCreated, "menu succesfuly updated")
 }
 
-// Delete a menu item.
+// Delete a menu item, ?cascade=true first takes it out of open orders and bundles.
 func (s *menuHandler) HandleDeleteMenuID(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	err := s.service.ServiceDeleteMenu(id)
+	byteValue, err := s.service.ServiceDeleteMenu(id, r.URL.Query().Get("cascade") == "true")
 	switch err {
+	case myerrors.ErrArchived,
+		myerrors.ErrBelowPaid:
+		response.SendError(w, http.StatusConflict, "Failed to delete menu", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to delete menu", err)
 		return
+	case myerrors.ErrHasDependents:
+		sendConflict(w, byteValue)
+		return
 	default:
 		if err != nil {
 			response.SendError(w, http.StatusInternalServerError, "Failed to delete menu", nil)
@@ -146,3 +217,87 @@ func (s *menuHandler) HandleDeleteMenuID(w http.ResponseWriter, r *http.Request)
 
 	response.SendMessage(w, http.StatusAccepted, "menu succesfuly deleted")
 }
+
+// Restore an archived menu item.
+func (s *menuHandler) HandleRestoreMenuID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceRestoreMenu(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to restore menu", err)
+		return
+	case myerrors.ErrUnknownIngredient,
+		myerrors.ErrInvalidComponent:
+		response.SendError(w, http.StatusConflict, "Failed to restore menu", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to restore menu", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "menu succesfuly restored")
+}
+
+// Import menu items from a JSON array or CSV.
+func (s *menuHandler) HandleImportMenu(w http.ResponseWriter, r *http.Request) {
+	isCSV, dryRun, upsert, err := importOptions(r)
+	if err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to import menu", err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to import menu", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceImportMenu(body, isCSV, dryRun, upsert)
+	sendImportResult(w, "Failed to import menu", byteValue, err)
+}
+
+// importOptions reads the body format from Content-Type and ?dry_run=true and ?mode=create|upsert.
+func importOptions(r *http.Request) (isCSV, dryRun, upsert bool, err error) {
+	contentType := r.Header.Get("Content-Type")
+	isCSV = validation.IsCSV(contentType)
+	if !isCSV && !validation.IsJSON(contentType) {
+		return false, false, false, myerrors.ErrInvalidContentType
+	}
+
+	dryRun = r.URL.Query().Get("dry_run") == "true"
+
+	switch r.URL.Query().Get("mode") {
+	case "", "create":
+	case "upsert":
+		upsert = true
+	default:
+		return false, false, false, myerrors.ErrInvalidImportMode
+	}
+
+	return isCSV, dryRun, upsert, nil
+}
+
+// sendConflict answers a refused delete with the dependents listed by the service.
+func sendConflict(w http.ResponseWriter, byteValue []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(byteValue)
+}
+
+func sendImportResult(w http.ResponseWriter, description string, byteValue []byte, err error) {
+	switch err {
+	case nil:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(byteValue)
+	case myerrors.ErrImportRejected:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(byteValue)
+	case myerrors.ErrFailUnmarshal, myerrors.ErrNoItems:
+		response.SendError(w, http.StatusBadRequest, description, err)
+	default:
+		response.SendError(w, http.StatusInternalServerError, description, nil)
+	}
+}
@@ -17,6 +17,8 @@ type OrderHandler interface {
 	HandlePostOrderClose(w http.ResponseWriter, r *http.Request)
 	HandlePutOrderID(w http.ResponseWriter, r *http.Request)
 	HandleDeleteOrder(w http.ResponseWriter, r *http.Request)
+	HandleRestoreOrder(w http.ResponseWriter, r *http.Request)
+	HandleRefundOrder(w http.ResponseWriter, r *http.Request)
 }
 
 type orderHandler struct {
@@ -27,16 +29,15 @@ func NewOrderHandler(service service.OrderService) OrderHandler {
 	return &orderHandler{service: service}
 }
 
-// Retrieve all orders.
+// Retrieve all orders, ?include_archived=true lists archived orders too.
 func (s *orderHandler) HandleGetOrder(w http.ResponseWriter, r *http.Request) {
-	byteValue, err := s.service.ServiceGetOrder()
+	byteValue, err := s.service.ServiceGetOrder(r.URL.Query().Get("include_archived") == "true")
 	if err != nil {
 		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve orders", nil)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Retrieve a specific order by ID.
@@ -52,8 +53,7 @@ func (s *orderHandler) HandleGetOrderID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(byteValue)
+	response.SendData(w, r, byteValue)
 }
 
 // Create a new order.
@@ -73,13 +73,24 @@ func (s *orderHandler) HandlePostOrder(w http.ResponseWriter, r *http.Request) {
 	err = s.service.ServicePostOrder(orderByte)
 	switch err {
 	case myerrors.ErrNameRequired,
+		myerrors.ErrUnknownCustomer,
 		myerrors.ErrItemsRequired,
 		myerrors.ErrIdRequired,
 		myerrors.ErrEmptyOrder,
 		myerrors.ErrAbsentItem,
-		myerrors.ErrInvalidQuantity:
+		myerrors.ErrUnitMismatch,
+		myerrors.ErrInvalidChoice,
+		myerrors.ErrInvalidComponent,
+		myerrors.ErrNotOrderableNow,
+		myerrors.ErrInvalidQuantity,
+		myerrors.ErrCustomerRequired,
+		myerrors.ErrUnknownRule,
+		myerrors.ErrNoRewardItem:
 		response.SendError(w, http.StatusBadRequest, "Failed to create order", err)
 		return
+	case myerrors.ErrInsufficientBalance:
+		response.SendError(w, http.StatusConflict, "Failed to create order", err)
+		return
 		////////////////////////////////////////////////////////////////////////////////////////////
 	default:
 		if err != nil {
@@ -97,7 +108,14 @@ func (s *orderHandler) HandlePostOrderClose(w http.ResponseWriter, r *http.Reque
 	err := s.service.ServicePostOrderClose(id)
 
 	switch err {
-	case myerrors.ErrOrderClosed:
+	case myerrors.ErrOrderClosed,
+		myerrors.ErrNegativeStock,
+		myerrors.ErrUnitMismatch,
+		myerrors.ErrInvalidChoice,
+		myerrors.ErrInvalidComponent:
+		response.SendError(w, http.StatusConflict, "Failed to close order", err)
+		return
+	case myerrors.ErrArchived:
 		response.SendError(w, http.StatusConflict, "Failed to close order", err)
 		return
 	case myerrors.ErrNotFound:
@@ -131,12 +149,23 @@ func (s *orderHandler) HandlePutOrderID(w http.ResponseWriter, r *http.Request)
 
 	switch err {
 	case myerrors.ErrNameRequired,
+		myerrors.ErrUnknownCustomer,
 		myerrors.ErrItemsRequired,
 		myerrors.ErrIdRequired,
 		myerrors.ErrInvalidQuantity,
+		myerrors.ErrInvalidChoice,
+		myerrors.ErrInvalidComponent,
+		myerrors.ErrCustomerRequired,
+		myerrors.ErrUnknownRule,
+		myerrors.ErrNoRewardItem,
+		myerrors.ErrBelowPaid,
+		myerrors.ErrAbsentItem,
 		myerrors.ErrOrderClosed:
 		response.SendError(w, http.StatusBadRequest, "Failed to update an order", err)
 		return
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to update an order", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to update an order", err)
 		return
@@ -156,6 +185,9 @@ func (s *orderHandler) HandleDeleteOrder(w http.ResponseWriter, r *http.Request)
 
 	err := s.service.ServiceDeleteOrder(id)
 	switch err {
+	case myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to delete an order", err)
+		return
 	case myerrors.ErrNotFound:
 		response.SendError(w, http.StatusNotFound, "Failed to delete an order", err)
 		return
@@ -167,3 +199,47 @@ func (s *orderHandler) HandleDeleteOrder(w http.ResponseWriter, r *http.Request)
 	}
 	response.SendMessage(w, http.StatusAccepted, "order succesfuly deleted")
 }
+
+// Restore an archived order.
+func (s *orderHandler) HandleRestoreOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := s.service.ServiceRestoreOrder(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to restore an order", err)
+		return
+	case myerrors.ErrUnknownRule,
+		myerrors.ErrInsufficientBalance:
+		response.SendError(w, http.StatusConflict, "Failed to restore an order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to restore an order", nil)
+			return
+		}
+	}
+	response.SendMessage(w, http.StatusOK, "order succesfuly restored")
+}
+
+// Refund a closed order and reverse its loyalty points and rewards.
+func (s *orderHandler) HandleRefundOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := s.service.ServiceRefundOrder(id)
+	switch err {
+	case myerrors.ErrOrderNotClosed,
+		myerrors.ErrArchived:
+		response.SendError(w, http.StatusConflict, "Failed to refund an order", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to refund an order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to refund an order", nil)
+			return
+		}
+	}
+	response.SendMessage(w, http.StatusOK, "order succesfuly refunded")
+}
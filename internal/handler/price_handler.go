@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PriceHandler interface {
+	HandleGetPrices(w http.ResponseWriter, r *http.Request)
+	HandlePostPrice(w http.ResponseWriter, r *http.Request)
+	HandleDeletePrice(w http.ResponseWriter, r *http.Request)
+}
+
+type priceHandler struct {
+	service service.PriceService
+}
+
+func NewPriceHandler(service service.PriceService) PriceHandler {
+	return &priceHandler{service: service}
+}
+
+// Retrieve the price history of a menu item.
+func (s *priceHandler) HandleGetPrices(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetPrices(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve prices", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve prices", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Change the price of a menu item now or at a later time.
+func (s *priceHandler) HandlePostPrice(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	priceByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to schedule price", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceSchedulePrice(id, priceByte)
+	switch err {
+	case myerrors.ErrPriceRequired,
+		myerrors.ErrInvalidEffectiveFrom,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to schedule price", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to schedule price", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to schedule price", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Cancel a scheduled price change.
+func (s *priceHandler) HandleDeletePrice(w http.ResponseWriter, r *http.Request) {
+	err := s.service.ServiceCancelPrice(r.PathValue("id"), r.PathValue("price_id"))
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to cancel price", err)
+		return
+	case myerrors.ErrPriceInEffect:
+		response.SendError(w, http.StatusConflict, "Failed to cancel price", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to cancel price", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "price succesfuly cancelled")
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PurchaseOrderHandler interface {
+	HandleGetPurchaseOrders(w http.ResponseWriter, r *http.Request)
+	HandleGetPurchaseOrderID(w http.ResponseWriter, r *http.Request)
+	HandlePostPurchaseOrder(w http.ResponseWriter, r *http.Request)
+	HandlePutPurchaseOrderID(w http.ResponseWriter, r *http.Request)
+	HandleDeletePurchaseOrder(w http.ResponseWriter, r *http.Request)
+	HandleSendPurchaseOrder(w http.ResponseWriter, r *http.Request)
+	HandleReceivePurchaseOrder(w http.ResponseWriter, r *http.Request)
+}
+
+type purchaseOrderHandler struct {
+	service service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service service.PurchaseOrderService) PurchaseOrderHandler {
+	return &purchaseOrderHandler{service: service}
+}
+
+// Retrieve purchase orders, optionally filtered by ?status=.
+func (s *purchaseOrderHandler) HandleGetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetPurchaseOrders(r.URL.Query().Get("status"))
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve purchase orders", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific purchase order.
+func (s *purchaseOrderHandler) HandleGetPurchaseOrderID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetPurchaseOrderID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve purchase order", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve purchase order", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Create a draft purchase order.
+func (s *purchaseOrderHandler) HandlePostPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	purchaseOrderByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create purchase order", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceCreatePurchaseOrder(purchaseOrderByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrLinesRequired,
+		myerrors.ErrInvalidQuantity,
+		myerrors.ErrDuplicateID,
+		myerrors.ErrNotSupplied:
+		response.SendError(w, http.StatusBadRequest, "Failed to create purchase order", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to create purchase order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create purchase order", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Update a draft purchase order.
+func (s *purchaseOrderHandler) HandlePutPurchaseOrderID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	purchaseOrderByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update purchase order", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdatePurchaseOrder(id, purchaseOrderByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrLinesRequired,
+		myerrors.ErrInvalidQuantity,
+		myerrors.ErrDuplicateID,
+		myerrors.ErrNotSupplied:
+		response.SendError(w, http.StatusBadRequest, "Failed to update purchase order", err)
+		return
+	case myerrors.ErrPurchaseOrderState:
+		response.SendError(w, http.StatusConflict, "Failed to update purchase order", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update purchase order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update purchase order", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "purchase order succesfuly updated")
+}
+
+// Delete a draft purchase order.
+func (s *purchaseOrderHandler) HandleDeletePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceDeletePurchaseOrder(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete purchase order", err)
+		return
+	case myerrors.ErrPurchaseOrderState:
+		response.SendError(w, http.StatusConflict, "Failed to delete purchase order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete purchase order", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "purchase order succesfuly deleted")
+}
+
+// Send a draft purchase order to the supplier.
+func (s *purchaseOrderHandler) HandleSendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceSendPurchaseOrder(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to send purchase order", err)
+		return
+	case myerrors.ErrPurchaseOrderState:
+		response.SendError(w, http.StatusConflict, "Failed to send purchase order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to send purchase order", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "purchase order succesfuly sent")
+}
+
+// Receive all or part of a sent purchase order.
+func (s *purchaseOrderHandler) HandleReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	receiptByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to receive purchase order", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceReceivePurchaseOrder(id, receiptByte)
+	switch err {
+	case myerrors.ErrInvalidQuantity,
+		myerrors.ErrOverReceipt,
+		myerrors.ErrInvalidExpiry,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to receive purchase order", err)
+		return
+	case myerrors.ErrPurchaseOrderState:
+		response.SendError(w, http.StatusConflict, "Failed to receive purchase order", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to receive purchase order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to receive purchase order", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
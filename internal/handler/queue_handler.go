@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"net/http"
+	"strconv"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type QueueHandler interface {
+	HandleGetQueue(w http.ResponseWriter, r *http.Request)
+	HandleToggleLine(w http.ResponseWriter, r *http.Request)
+	HandleBumpOrder(w http.ResponseWriter, r *http.Request)
+}
+
+type queueHandler struct {
+	service service.QueueService
+}
+
+func NewQueueHandler(service service.QueueService) QueueHandler {
+	return &queueHandler{service: service}
+}
+
+// Retrieve the queue of every station, ?station=bar only the bar's.
+func (s *queueHandler) HandleGetQueue(w http.ResponseWriter, r *http.Request) {
+	station := r.URL.Query().Get("station")
+	if err := validation.CheckStation(station); err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to retrieve queue", err)
+		return
+	}
+
+	byteValue, err := s.service.ServiceGetQueue(station)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve queue", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Toggle whether a line of an order, or the part of it made at ?station=, is made.
+func (s *queueHandler) HandleToggleLine(w http.ResponseWriter, r *http.Request) {
+	line, err := strconv.Atoi(r.PathValue("line"))
+	if err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to toggle line", myerrors.ErrInvalidLine)
+		return
+	}
+
+	station := r.URL.Query().Get("station")
+	if err := validation.CheckStation(station); err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to toggle line", err)
+		return
+	}
+
+	byteValue, err := s.service.ServiceToggleLine(r.PathValue("id"), line, station)
+	switch err {
+	case myerrors.ErrInvalidLine:
+		response.SendError(w, http.StatusBadRequest, "Failed to toggle line", err)
+		return
+	case myerrors.ErrNotInQueue:
+		response.SendError(w, http.StatusConflict, "Failed to toggle line", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to toggle line", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to toggle line", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Bump an order off a station, ?station=food, or off every station.
+func (s *queueHandler) HandleBumpOrder(w http.ResponseWriter, r *http.Request) {
+	station := r.URL.Query().Get("station")
+	if err := validation.CheckStation(station); err != nil {
+		response.SendError(w, http.StatusBadRequest, "Failed to bump order", err)
+		return
+	}
+
+	byteValue, err := s.service.ServiceBumpOrder(r.PathValue("id"), station)
+	switch err {
+	case myerrors.ErrNotInQueue:
+		response.SendError(w, http.StatusConflict, "Failed to bump order", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to bump order", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to bump order", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type StocktakeHandler interface {
+	HandleGetStocktakes(w http.ResponseWriter, r *http.Request)
+	HandleGetStocktakeID(w http.ResponseWriter, r *http.Request)
+	HandlePostStocktake(w http.ResponseWriter, r *http.Request)
+	HandleSubmitCounts(w http.ResponseWriter, r *http.Request)
+	HandleCommitStocktake(w http.ResponseWriter, r *http.Request)
+}
+
+type stocktakeHandler struct {
+	service service.StocktakeService
+}
+
+func NewStocktakeHandler(service service.StocktakeService) StocktakeHandler {
+	return &stocktakeHandler{service: service}
+}
+
+// Retrieve stocktakes, optionally filtered by ?status=.
+func (s *stocktakeHandler) HandleGetStocktakes(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetStocktakes(r.URL.Query().Get("status"))
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve stocktakes", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific stocktake with its variance.
+func (s *stocktakeHandler) HandleGetStocktakeID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetStocktakeID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve stocktake", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve stocktake", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Start a new stocktake.
+func (s *stocktakeHandler) HandlePostStocktake(w http.ResponseWriter, r *http.Request) {
+	stocktakeByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to start stocktake", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceStartStocktake(stocktakeByte)
+	switch err {
+	case myerrors.ErrUnknownIngredient,
+		myerrors.ErrDuplicateID,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to start stocktake", err)
+		return
+	case myerrors.ErrStocktakeOpen:
+		response.SendError(w, http.StatusConflict, "Failed to start stocktake", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to start stocktake", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Submit counted quantities for an open stocktake.
+func (s *stocktakeHandler) HandleSubmitCounts(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	countsByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to submit counts", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceSubmitCounts(id, countsByte)
+	switch err {
+	case myerrors.ErrCountsRequired,
+		myerrors.ErrIdRequired,
+		myerrors.ErrInvalidQuantity,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to submit counts", err)
+		return
+	case myerrors.ErrStocktakeCommitted:
+		response.SendError(w, http.StatusConflict, "Failed to submit counts", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to submit counts", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to submit counts", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Commit a stocktake, writing adjustment movements for the variance.
+func (s *stocktakeHandler) HandleCommitStocktake(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceCommitStocktake(id)
+	switch err {
+	case myerrors.ErrStocktakeCommitted:
+		response.SendError(w, http.StatusConflict, "Failed to commit stocktake", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to commit stocktake", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to commit stocktake", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
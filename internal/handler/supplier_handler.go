@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type SupplierHandler interface {
+	HandleGetSuppliers(w http.ResponseWriter, r *http.Request)
+	HandleGetSupplierID(w http.ResponseWriter, r *http.Request)
+	HandlePostSupplier(w http.ResponseWriter, r *http.Request)
+	HandlePutSupplierID(w http.ResponseWriter, r *http.Request)
+	HandleDeleteSupplier(w http.ResponseWriter, r *http.Request)
+}
+
+type supplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(service service.SupplierService) SupplierHandler {
+	return &supplierHandler{service: service}
+}
+
+// Retrieve all suppliers.
+func (s *supplierHandler) HandleGetSuppliers(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetSuppliers()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve suppliers", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific supplier.
+func (s *supplierHandler) HandleGetSupplierID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetSupplierID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve supplier", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve supplier", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a new supplier.
+func (s *supplierHandler) HandlePostSupplier(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	supplierByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create supplier", nil)
+		return
+	}
+
+	err = s.service.ServiceCreateSupplier(supplierByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrNameRequired,
+		myerrors.ErrPriceRequired,
+		myerrors.ErrInvalidPackSize,
+		myerrors.ErrUnknownIngredient,
+		myerrors.ErrIDExist:
+		response.SendError(w, http.StatusBadRequest, "Failed to create supplier", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create supplier", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "supplier succesfuly created")
+}
+
+// Update a supplier.
+func (s *supplierHandler) HandlePutSupplierID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	supplierByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update supplier", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdateSupplier(id, supplierByte)
+	switch err {
+	case myerrors.ErrIdRequired,
+		myerrors.ErrNameRequired,
+		myerrors.ErrPriceRequired,
+		myerrors.ErrInvalidPackSize,
+		myerrors.ErrUnknownIngredient:
+		response.SendError(w, http.StatusBadRequest, "Failed to update supplier", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update supplier", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update supplier", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "supplier succesfuly updated")
+}
+
+// Delete a supplier.
+func (s *supplierHandler) HandleDeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceDeleteSupplier(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete supplier", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete supplier", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "supplier succesfuly deleted")
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type UnitHandler interface {
+	HandleGetUnits(w http.ResponseWriter, r *http.Request)
+	HandlePostUnit(w http.ResponseWriter, r *http.Request)
+	HandleDeleteUnit(w http.ResponseWriter, r *http.Request)
+}
+
+type unitHandler struct {
+	service service.UnitService
+}
+
+func NewUnitHandler(service service.UnitService) UnitHandler {
+	return &unitHandler{service: service}
+}
+
+// Retrieve all units of measure.
+func (s *unitHandler) HandleGetUnits(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetUnits()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve units", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a custom unit.
+func (s *unitHandler) HandlePostUnit(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	unitByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create unit", nil)
+		return
+	}
+
+	err = s.service.ServiceCreateUnit(unitByte)
+	switch err {
+	case myerrors.ErrUnitRequired,
+		myerrors.ErrBaseRequired,
+		myerrors.ErrInvalidFactor,
+		myerrors.ErrBuiltinUnit,
+		myerrors.ErrUnitCycle,
+		myerrors.ErrIDExist:
+		response.SendError(w, http.StatusBadRequest, "Failed to create unit", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create unit", myerrors.ErrInvalidJson)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusOK, "unit succesfuly created")
+}
+
+// Delete a custom unit.
+func (s *unitHandler) HandleDeleteUnit(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("unit")
+	err := s.service.ServiceDeleteUnit(name)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to delete unit", err)
+		return
+	case myerrors.ErrBuiltinUnit:
+		response.SendError(w, http.StatusBadRequest, "Failed to delete unit", err)
+		return
+	case myerrors.ErrUnitInUse:
+		response.SendError(w, http.StatusConflict, "Failed to delete unit", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to delete unit", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "unit succesfuly deleted")
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"hot-coffee/internal/service"
+	"hot-coffee/internal/utils/response"
+	"hot-coffee/internal/utils/validation"
+	"io"
+	"net/http"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type WebhookHandler interface {
+	HandleGetWebhooks(w http.ResponseWriter, r *http.Request)
+	HandleGetWebhookID(w http.ResponseWriter, r *http.Request)
+	HandlePostWebhook(w http.ResponseWriter, r *http.Request)
+	HandlePutWebhookID(w http.ResponseWriter, r *http.Request)
+	HandleDeleteWebhook(w http.ResponseWriter, r *http.Request)
+	HandleGetDeliveries(w http.ResponseWriter, r *http.Request)
+	HandleTestWebhook(w http.ResponseWriter, r *http.Request)
+	HandleGetDeadLetters(w http.ResponseWriter, r *http.Request)
+	HandleRetryDelivery(w http.ResponseWriter, r *http.Request)
+}
+
+type webhookHandler struct {
+	service service.WebhookService
+}
+
+func NewWebhookHandler(service service.WebhookService) WebhookHandler {
+	return &webhookHandler{service: service}
+}
+
+// Retrieve all webhooks.
+func (s *webhookHandler) HandleGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetWebhooks()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve webhooks", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve a specific webhook.
+func (s *webhookHandler) HandleGetWebhookID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetWebhookID(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve webhook", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve webhook", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Add a new webhook, the response carries the generated webhook_id and secret.
+func (s *webhookHandler) HandlePostWebhook(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	webhookByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to create webhook", nil)
+		return
+	}
+
+	byteValue, err := s.service.ServiceCreateWebhook(webhookByte)
+	switch err {
+	case myerrors.ErrInvalidURL,
+		myerrors.ErrUnknownEventType,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to create webhook", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to create webhook", nil)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(byteValue)
+}
+
+// Update a webhook.
+func (s *webhookHandler) HandlePutWebhookID(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if !validation.IsJSON(contentType) {
+		response.SendError(w, http.StatusBadRequest, "Not a JSON", nil)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	webhookByte, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to update webhook", nil)
+		return
+	}
+
+	err = s.service.ServiceUpdateWebhook(id, webhookByte)
+	switch err {
+	case myerrors.ErrInvalidURL,
+		myerrors.ErrUnknownEventType,
+		myerrors.ErrFailUnmarshal:
+		response.SendError(w, http.StatusBadRequest, "Failed to update webhook", err)
+		return
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to update webhook", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to update webhook", nil)
+			return
+		}
+	}
+
+	response.SendMessage(w, http.StatusCreated, "webhook succesfuly updated")
+}
+
+// Delete a webhook.
+func (s *webhookHandler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.service.ServiceDeleteWebhook(id)
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to delete webhook", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to delete webhook", nil)
+		return
+	}
+
+	response.SendMessage(w, http.StatusAccepted, "webhook succesfuly deleted")
+}
+
+// Retrieve the delivery log of a webhook, ?status= filters it.
+func (s *webhookHandler) HandleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceGetDeliveries(id, r.URL.Query().Get("status"))
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to retrieve deliveries", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve deliveries", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Send a test event to a webhook.
+func (s *webhookHandler) HandleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceTestWebhook(id)
+
+	if err == myerrors.ErrNotFound {
+		response.SendError(w, http.StatusNotFound, "Failed to test webhook", err)
+		return
+	} else if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to test webhook", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Retrieve the deliveries that ran out of attempts.
+func (s *webhookHandler) HandleGetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	byteValue, err := s.service.ServiceGetDeadLetters()
+	if err != nil {
+		response.SendError(w, http.StatusInternalServerError, "Failed to retrieve dead letters", nil)
+		return
+	}
+
+	response.SendData(w, r, byteValue)
+}
+
+// Attempt a dead delivery once more.
+func (s *webhookHandler) HandleRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	byteValue, err := s.service.ServiceRetryDelivery(id)
+	switch err {
+	case myerrors.ErrNotFound:
+		response.SendError(w, http.StatusNotFound, "Failed to retry delivery", err)
+		return
+	case myerrors.ErrNotDeadLetter:
+		response.SendError(w, http.StatusConflict, "Failed to retry delivery", err)
+		return
+	default:
+		if err != nil {
+			response.SendError(w, http.StatusInternalServerError, "Failed to retry delivery", nil)
+			return
+		}
+	}
+
+	response.SendData(w, r, byteValue)
+}
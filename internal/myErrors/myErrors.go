@@ -25,4 +25,81 @@ var (
 	ErrUnitRequired         = errors.New("Unit field is required")
 	ErrItemsRequired        = errors.New("Items field are required")
 	ErrNotEnoughIngridients = errors.New("Not enough ingridients")
+	ErrInvalidPeriod        = errors.New("Period is invalid, use RFC3339 or YYYY-MM-DD and from before to")
+	ErrInvalidThreshold     = errors.New("Threshold must be a non-negative number")
+	ErrInvalidPrice         = errors.New("Price field is invalid")
+	ErrDuplicateID          = errors.New("ID is repeated in the import")
+	ErrImportRejected       = errors.New("Import rejected, nothing was applied")
+	ErrInvalidImportMode    = errors.New("Import mode must be create or upsert")
+	ErrInvalidContentType   = errors.New("Content-Type must be application/json or text/csv")
+	ErrInvalidMinQuantity   = errors.New("Min quantity field must be >=0")
+	ErrAlertNotOpen         = errors.New("Only open alerts can be acknowledged")
+	ErrAlertResolved        = errors.New("Alert is already resolved")
+	ErrInvalidPackSize      = errors.New("Pack size field must be >0")
+	ErrUnknownIngredient    = errors.New("Ingredient is not in the inventory")
+	ErrNotSupplied          = errors.New("Supplier does not carry this ingredient")
+	ErrLinesRequired        = errors.New("Lines field is required")
+	ErrPurchaseOrderState   = errors.New("Action is not allowed in the current purchase order status")
+	ErrOverReceipt          = errors.New("Received packs exceed the outstanding quantity")
+	ErrInvalidDelta         = errors.New("Delta field must not be 0")
+	ErrInvalidReason        = errors.New("Reason must be one of sale, receipt, waste, adjustment, transfer, refund")
+	ErrNegativeStock        = errors.New("Stock can not go below zero")
+	ErrUnitMismatch         = errors.New("Recipe unit can not be converted to the inventory unit")
+	ErrBaseRequired         = errors.New("Base field is required")
+	ErrInvalidFactor        = errors.New("Factor field must be >0")
+	ErrBuiltinUnit          = errors.New("Predefined units can not be changed")
+	ErrUnitInUse            = errors.New("Unit is used by the inventory, a recipe or another unit")
+	ErrUnitCycle            = errors.New("Unit base can not lead back to the unit itself")
+	ErrInvalidExpiry        = errors.New("Expiry date is invalid, use RFC3339 or YYYY-MM-DD")
+	ErrInvalidDays          = errors.New("Days must be a non-negative number")
+	ErrStocktakeOpen        = errors.New("Another stocktake is still open")
+	ErrStocktakeCommitted   = errors.New("Stocktake is already committed")
+	ErrCountsRequired       = errors.New("Counts are required")
+	ErrInvalidLeadTime      = errors.New("Lead time must be a non-negative number of days")
+	ErrInvalidTargetCover   = errors.New("Target cover must be a non-negative number of days")
+	ErrUnknownCategory      = errors.New("Category does not exist")
+	ErrCategoryInUse        = errors.New("Category still has menu items or loyalty rules")
+	ErrInvalidSortOrder     = errors.New("Sort order must be a whole number")
+	ErrSlotRequired         = errors.New("Slot name is required")
+	ErrOptionsRequired      = errors.New("Slot options are required")
+	ErrInvalidComponent     = errors.New("Bundle components must be existing menu items that are not bundles")
+	ErrInvalidChoice        = errors.New("Choices must pick one option for every slot of the bundle")
+	ErrInvalidSchedule      = errors.New("Schedule is invalid, use days mon-sun, HH:MM times and YYYY-MM-DD dates")
+	ErrNotOrderableNow      = errors.New("Menu item cannot be ordered at this time")
+	ErrInvalidTimestamp     = errors.New("Time must be RFC3339 or YYYY-MM-DD")
+	ErrInvalidEffectiveFrom = errors.New("Effective from must be an RFC3339 time that is not in the past")
+	ErrPriceInEffect        = errors.New("Price change is already in effect")
+	ErrUnknownAllergen      = errors.New("Unknown allergen")
+	ErrInvalidNutrition     = errors.New("Nutrition values must be >=0")
+	ErrHasDependents        = errors.New("Still referenced by other records") // 409
+	ErrArchived             = errors.New("Record is archived")                // 409
+	ErrInvalidEmail         = errors.New("Email is not a valid address")
+	ErrUnknownCustomer      = errors.New("Customer does not exist")
+	ErrDuplicateCustomer    = errors.New("Another customer has this email or phone") // 409
+	ErrInvalidRuleType      = errors.New("Loyalty rule type must be points or stamps")
+	ErrInvalidPointsRate    = errors.New("Points per unit must be greater than zero")
+	ErrCategoryRequired     = errors.New("Stamps rule requires a category")
+	ErrInvalidRewardCost    = errors.New("Reward cost must be greater than zero")
+	ErrInvalidRewardValue   = errors.New("Reward value must be greater than zero")
+	ErrUnknownRule          = errors.New("Loyalty rule does not exist or is not active")
+	ErrRuleInUse            = errors.New("Loyalty rule has transactions, deactivate it instead")
+	ErrCustomerRequired     = errors.New("Redeeming rewards requires a customer_id")
+	ErrInsufficientBalance  = errors.New("Not enough points or stamps for the rewards")
+	ErrNoRewardItem         = errors.New("Order has no item of the rule's category to make free")
+	ErrOrderNotClosed       = errors.New("Only closed orders can be refunded")
+	ErrInvalidAmount        = errors.New("Amount must be greater than zero")
+	ErrGiftCardVoid         = errors.New("Gift card is void")
+	ErrInsufficientFunds    = errors.New("Gift card balance is too low")
+	ErrOrderRefunded        = errors.New("Order is refunded")
+	ErrOrderPaid            = errors.New("Order is already paid")
+	ErrOverpayment          = errors.New("Amount is more than what is left to pay")
+	ErrInvalidStation       = errors.New("Station must be bar or food")
+	ErrInvalidLine          = errors.New("Order has no such line")
+	ErrNotInQueue           = errors.New("Order is not in the queue")
+	ErrUnknownTopic         = errors.New("Topic must be orders, menu or inventory")
+	ErrInvalidEventID       = errors.New("Last event ID must be a number")
+	ErrBelowPaid            = errors.New("Order total cannot be less than what was paid with gift cards")
+	ErrInvalidURL           = errors.New("URL must be an absolute http or https URL")
+	ErrUnknownEventType     = errors.New("Unknown event type or topic")
+	ErrNotDeadLetter        = errors.New("Only dead deliveries can be retried")
 )
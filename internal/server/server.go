@@ -1,12 +1,15 @@
 package server
 
 import (
+	"fmt"
 	"hot-coffee/internal/config"
 	"hot-coffee/internal/dal"
 	"hot-coffee/internal/handler"
 	"hot-coffee/internal/service"
 	"log"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 func StartServer() {
@@ -15,16 +18,65 @@ func StartServer() {
 	orderRepo := dal.NewOrderRepository("orders.json")
 	menuRepo := dal.NewMenuRepository("menu_items.json")
 	inventoryRepo := dal.NewInventoryRepository("inventory_item.json")
+	movementRepo := dal.NewMovementRepository("inventory_movements.json")
+	alertRepo := dal.NewAlertRepository("stock_alerts.json")
+	supplierRepo := dal.NewSupplierRepository("suppliers.json")
+	purchaseOrderRepo := dal.NewPurchaseOrderRepository("purchase_orders.json")
+	unitRepo := dal.NewUnitRepository("units.json")
+	lotRepo := dal.NewLotRepository("inventory_lots.json")
+	stocktakeRepo := dal.NewStocktakeRepository("stocktakes.json")
+	categoryRepo := dal.NewCategoryRepository("categories.json")
+	priceRepo := dal.NewPriceRepository("menu_prices.json")
+	recipeRepo := dal.NewRecipeVersionRepository("recipe_versions.json")
+	customerRepo := dal.NewCustomerRepository("customers.json")
+	ruleRepo := dal.NewLoyaltyRuleRepository("loyalty_rules.json")
+	loyaltyRepo := dal.NewLoyaltyTransactionRepository("loyalty_transactions.json")
+	giftCardRepo := dal.NewGiftCardRepository("gift_cards.json")
+	giftCardLogRepo := dal.NewGiftCardTransactionRepository("gift_card_transactions.json")
+	webhookRepo := dal.NewWebhookRepository("webhooks.json")
+	deliveryRepo := dal.NewWebhookDeliveryRepository("webhook_deliveries.json")
 
-	orderService := service.NewOrderService(orderRepo, menuRepo, inventoryRepo)
-	menuService := service.NewMenuService(menuRepo)
-	inventoryService := service.NewInventoryService(inventoryRepo)
-	aggregationsService := service.NewAggregationsService(menuRepo, orderRepo)
+	events := service.NewEventBus(*config.EventBuffer)
+	alertService := service.NewAlertService(alertRepo, inventoryRepo, *config.AlertWebhook, events)
+	stockLedger := service.NewStockLedger(inventoryRepo, movementRepo, lotRepo, alertService, events)
+
+	orderService := service.NewOrderService(orderRepo, menuRepo, recipeRepo, customerRepo, inventoryRepo, unitRepo, categoryRepo, priceRepo, ruleRepo, loyaltyRepo, giftCardRepo, giftCardLogRepo, stockLedger, events, config.Location)
+	menuService := service.NewMenuService(menuRepo, recipeRepo, orderRepo, inventoryRepo, unitRepo, categoryRepo, priceRepo, ruleRepo, loyaltyRepo, giftCardRepo, giftCardLogRepo, orderService, events, config.Location)
+	inventoryService := service.NewInventoryService(inventoryRepo, movementRepo, menuRepo, recipeRepo, supplierRepo, unitRepo, lotRepo, purchaseOrderRepo, stocktakeRepo, stockLedger, events)
+	aggregationsService := service.NewAggregationsService(menuRepo, recipeRepo, orderRepo, inventoryRepo, movementRepo, unitRepo)
+	supplierService := service.NewSupplierService(supplierRepo, inventoryRepo)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, stockLedger)
+	unitService := service.NewUnitService(unitRepo, menuRepo, recipeRepo, orderRepo, inventoryRepo)
+	lotService := service.NewLotService(lotRepo, inventoryRepo, stockLedger)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, inventoryRepo, stockLedger)
+	categoryService := service.NewCategoryService(categoryRepo, menuRepo, ruleRepo)
+	priceService := service.NewPriceService(priceRepo, menuRepo)
+	customerService := service.NewCustomerService(customerRepo, orderRepo, giftCardRepo, loyaltyRepo)
+	loyaltyService := service.NewLoyaltyService(ruleRepo, loyaltyRepo, customerRepo, categoryRepo)
+	giftCardService := service.NewGiftCardService(giftCardRepo, giftCardLogRepo, customerRepo, orderRepo, orderService, events)
+	queueService := service.NewQueueService(orderRepo, menuRepo, categoryRepo, orderService, events)
+	webhookService := service.NewWebhookService(webhookRepo, deliveryRepo, events, *config.WebhookAttempts, *config.WebhookBackoff)
+	forecastService := service.NewForecastService(orderRepo, menuRepo, recipeRepo, inventoryRepo, unitRepo, purchaseOrderRepo, supplierRepo)
 
 	orderHandler := handler.NewOrderHandler(orderService)
 	menuHandler := handler.NewMenuHandler(menuService)
 	inventoryHandler := handler.NewInventoryHandler(inventoryService)
 	aggregationsHandlers := handler.NewAggregationsHandler(aggregationsService)
+	alertHandler := handler.NewAlertHandler(alertService)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+	unitHandler := handler.NewUnitHandler(unitService)
+	lotHandler := handler.NewLotHandler(lotService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+	forecastHandler := handler.NewForecastHandler(forecastService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	priceHandler := handler.NewPriceHandler(priceService)
+	customerHandler := handler.NewCustomerHandler(customerService)
+	loyaltyHandler := handler.NewLoyaltyHandler(loyaltyService)
+	giftCardHandler := handler.NewGiftCardHandler(giftCardService)
+	queueHandler := handler.NewQueueHandler(queueService)
+	eventHandler := handler.NewEventHandler(events)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 
 	// ORDERS
 	mux.HandleFunc("GET /orders", orderHandler.HandleGetOrder)
@@ -33,26 +85,157 @@ func StartServer() {
 	mux.HandleFunc("POST /orders/{id}/close", orderHandler.HandlePostOrderClose)
 	mux.HandleFunc("PUT /orders/{id}", orderHandler.HandlePutOrderID)
 	mux.HandleFunc("DELETE /orders/{id}", orderHandler.HandleDeleteOrder)
+	mux.HandleFunc("POST /orders/{id}/restore", orderHandler.HandleRestoreOrder)
+	mux.HandleFunc("POST /orders/{id}/refund", orderHandler.HandleRefundOrder)
+	mux.HandleFunc("POST /orders/{id}/gift-card", giftCardHandler.HandlePayOrder)
+
+	// EVENTS
+	mux.HandleFunc("GET /events", eventHandler.HandleGetEvents)
+
+	// WEBHOOKS
+	mux.HandleFunc("GET /webhooks", webhookHandler.HandleGetWebhooks)
+	mux.HandleFunc("GET /webhooks/{id}", webhookHandler.HandleGetWebhookID)
+	mux.HandleFunc("POST /webhooks", webhookHandler.HandlePostWebhook)
+	mux.HandleFunc("PUT /webhooks/{id}", webhookHandler.HandlePutWebhookID)
+	mux.HandleFunc("DELETE /webhooks/{id}", webhookHandler.HandleDeleteWebhook)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandler.HandleGetDeliveries)
+	mux.HandleFunc("POST /webhooks/{id}/test", webhookHandler.HandleTestWebhook)
+	mux.HandleFunc("GET /webhooks/dead-letters", webhookHandler.HandleGetDeadLetters)
+	mux.HandleFunc("POST /webhooks/dead-letters/{id}/retry", webhookHandler.HandleRetryDelivery)
+
+	// QUEUE
+	mux.HandleFunc("GET /queue", queueHandler.HandleGetQueue)
+	mux.HandleFunc("POST /queue/{id}/lines/{line}/toggle", queueHandler.HandleToggleLine)
+	mux.HandleFunc("POST /queue/{id}/bump", queueHandler.HandleBumpOrder)
+
+	// CUSTOMERS
+	mux.HandleFunc("GET /customers", customerHandler.HandleGetCustomers)
+	mux.HandleFunc("GET /customers/{id}", customerHandler.HandleGetCustomerID)
+	mux.HandleFunc("POST /customers", customerHandler.HandlePostCustomer)
+	mux.HandleFunc("PUT /customers/{id}", customerHandler.HandlePutCustomerID)
+	mux.HandleFunc("DELETE /customers/{id}", customerHandler.HandleDeleteCustomer)
+	mux.HandleFunc("GET /customers/{id}/orders", customerHandler.HandleGetCustomerOrders)
+	mux.HandleFunc("GET /customers/{id}/loyalty", loyaltyHandler.HandleGetAccount)
+
+	// LOYALTY
+	mux.HandleFunc("GET /loyalty/rules", loyaltyHandler.HandleGetRules)
+	mux.HandleFunc("GET /loyalty/rules/{id}", loyaltyHandler.HandleGetRuleID)
+	mux.HandleFunc("POST /loyalty/rules", loyaltyHandler.HandlePostRule)
+	mux.HandleFunc("PUT /loyalty/rules/{id}", loyaltyHandler.HandlePutRuleID)
+	mux.HandleFunc("DELETE /loyalty/rules/{id}", loyaltyHandler.HandleDeleteRule)
+
+	// GIFT CARDS
+	mux.HandleFunc("GET /gift-cards", giftCardHandler.HandleGetGiftCards)
+	mux.HandleFunc("GET /gift-cards/{code}", giftCardHandler.HandleGetGiftCard)
+	mux.HandleFunc("POST /gift-cards", giftCardHandler.HandleIssueGiftCard)
+	mux.HandleFunc("POST /gift-cards/{code}/top-up", giftCardHandler.HandleTopUpGiftCard)
+	mux.HandleFunc("POST /gift-cards/{code}/redeem", giftCardHandler.HandleRedeemGiftCard)
+	mux.HandleFunc("POST /gift-cards/{code}/void", giftCardHandler.HandleVoidGiftCard)
 
 	// //MENU
 	mux.HandleFunc("GET /menu", menuHandler.HandleGetMenu)
 	mux.HandleFunc("GET /menu/{id}", menuHandler.HandleGetMenuID)
 	mux.HandleFunc("POST /menu", menuHandler.HandlePostMenu)
+	mux.HandleFunc("POST /menu/import", menuHandler.HandleImportMenu)
 	mux.HandleFunc("PUT /menu/{id}", menuHandler.HandlePutMenuID)
 	mux.HandleFunc("DELETE /menu/{id}", menuHandler.HandleDeleteMenuID)
+	mux.HandleFunc("POST /menu/{id}/restore", menuHandler.HandleRestoreMenuID)
+	mux.HandleFunc("GET /menu/{id}/versions", menuHandler.HandleGetRecipeVersions)
+	mux.HandleFunc("GET /menu/{id}/prices", priceHandler.HandleGetPrices)
+	mux.HandleFunc("POST /menu/{id}/prices", priceHandler.HandlePostPrice)
+	mux.HandleFunc("DELETE /menu/{id}/prices/{price_id}", priceHandler.HandleDeletePrice)
+
+	// CATEGORIES
+	mux.HandleFunc("GET /categories", categoryHandler.HandleGetCategories)
+	mux.HandleFunc("GET /categories/{id}", categoryHandler.HandleGetCategoryID)
+	mux.HandleFunc("POST /categories", categoryHandler.HandlePostCategory)
+	mux.HandleFunc("PUT /categories/{id}", categoryHandler.HandlePutCategoryID)
+	mux.HandleFunc("DELETE /categories/{id}", categoryHandler.HandleDeleteCategory)
 
 	// //INVENTORY
 	mux.HandleFunc("GET /inventory", inventoryHandler.HandleGetInventory)
 	mux.HandleFunc("GET /inventory/{id}", inventoryHandler.HandleGetInventoryID)
 	mux.HandleFunc("POST /inventory", inventoryHandler.HandlePostInventory)
+	mux.HandleFunc("POST /inventory/import", inventoryHandler.HandleImportInventory)
 	mux.HandleFunc("PUT /inventory/{id}", inventoryHandler.HandlePutInventoryID)
 	mux.HandleFunc("DELETE /inventory/{id}", inventoryHandler.HandleDeleteInventory)
+	mux.HandleFunc("POST /inventory/{id}/restore", inventoryHandler.HandleRestoreInventory)
+	mux.HandleFunc("POST /inventory/{id}/adjust", inventoryHandler.HandleAdjustInventory)
+	mux.HandleFunc("GET /inventory/{id}/movements", inventoryHandler.HandleGetMovements)
+	mux.HandleFunc("GET /inventory/alerts", alertHandler.HandleGetAlerts)
+	mux.HandleFunc("POST /inventory/alerts/{id}/acknowledge", alertHandler.HandleAcknowledgeAlert)
+	mux.HandleFunc("POST /inventory/alerts/{id}/resolve", alertHandler.HandleResolveAlert)
+	mux.HandleFunc("GET /inventory/{id}/lots", lotHandler.HandleGetLots)
+	mux.HandleFunc("POST /inventory/{id}/lots", lotHandler.HandlePostLot)
+	mux.HandleFunc("GET /inventory/lots/expiring", lotHandler.HandleGetExpiringLots)
+	mux.HandleFunc("GET /inventory/forecast", forecastHandler.HandleGetForecast)
+
+	// STOCKTAKES
+	mux.HandleFunc("GET /stocktakes", stocktakeHandler.HandleGetStocktakes)
+	mux.HandleFunc("GET /stocktakes/{id}", stocktakeHandler.HandleGetStocktakeID)
+	mux.HandleFunc("POST /stocktakes", stocktakeHandler.HandlePostStocktake)
+	mux.HandleFunc("POST /stocktakes/{id}/counts", stocktakeHandler.HandleSubmitCounts)
+	mux.HandleFunc("POST /stocktakes/{id}/commit", stocktakeHandler.HandleCommitStocktake)
+
+	// UNITS
+	mux.HandleFunc("GET /units", unitHandler.HandleGetUnits)
+	mux.HandleFunc("POST /units", unitHandler.HandlePostUnit)
+	mux.HandleFunc("DELETE /units/{unit}", unitHandler.HandleDeleteUnit)
+
+	// SUPPLIERS
+	mux.HandleFunc("GET /suppliers", supplierHandler.HandleGetSuppliers)
+	mux.HandleFunc("GET /suppliers/{id}", supplierHandler.HandleGetSupplierID)
+	mux.HandleFunc("POST /suppliers", supplierHandler.HandlePostSupplier)
+	mux.HandleFunc("PUT /suppliers/{id}", supplierHandler.HandlePutSupplierID)
+	mux.HandleFunc("DELETE /suppliers/{id}", supplierHandler.HandleDeleteSupplier)
+
+	// PURCHASE ORDERS
+	mux.HandleFunc("GET /purchase-orders", purchaseOrderHandler.HandleGetPurchaseOrders)
+	mux.HandleFunc("GET /purchase-orders/{id}", purchaseOrderHandler.HandleGetPurchaseOrderID)
+	mux.HandleFunc("POST /purchase-orders", purchaseOrderHandler.HandlePostPurchaseOrder)
+	mux.HandleFunc("PUT /purchase-orders/{id}", purchaseOrderHandler.HandlePutPurchaseOrderID)
+	mux.HandleFunc("DELETE /purchase-orders/{id}", purchaseOrderHandler.HandleDeletePurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/send", purchaseOrderHandler.HandleSendPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/receive", purchaseOrderHandler.HandleReceivePurchaseOrder)
 
 	// //AGREGATIONS
 	mux.HandleFunc("GET /reports/total-sales", aggregationsHandlers.HandleGetSales)
 	mux.HandleFunc("GET /reports/popular-items", aggregationsHandlers.HandleGetPopItems)
+	mux.HandleFunc("GET /reports/consumption", aggregationsHandlers.HandleGetConsumption)
+	mux.HandleFunc("GET /reports/component-sales", aggregationsHandlers.HandleGetComponentSales)
+
+	go expireLots(lotService, *config.ExpiryInterval)
+	webhookService.Start()
 
 	if err := http.ListenAndServe(":"+*config.Port, mux); err != nil {
 		log.Fatal("Failed to launch server ", err)
 	}
 }
+
+// Purge removes the records archived longer than the retention period and prints
+// what was removed.
+func Purge() {
+	menuRepo := dal.NewMenuRepository("menu_items.json")
+	inventoryRepo := dal.NewInventoryRepository("inventory_item.json")
+	orderRepo := dal.NewOrderRepository("orders.json")
+
+	purgeService := service.NewPurgeService(menuRepo, inventoryRepo, orderRepo)
+	result, err := purgeService.ServicePurge(*config.Retention)
+	if err != nil {
+		log.Fatal("Failed to purge archived records ", err)
+	}
+	fmt.Println(string(result))
+}
+
+// expireLots writes off expired lots at startup and then on every tick.
+func expireLots(lotService service.LotService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := lotService.ServiceExpireLots(); err != nil {
+			slog.Error("Failed to expire lots", "error", err)
+		}
+		<-ticker.C
+	}
+}
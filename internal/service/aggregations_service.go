@@ -6,7 +6,9 @@ import (
 	"hot-coffee/internal/dal"
 	"hot-coffee/models"
 	"log/slog"
+	"math"
 	"sort"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
@@ -14,17 +16,27 @@ import (
 type AggregationsService interface {
 	ServiceGetTotal() ([]byte, error)
 	ServiceGetPopular() ([]byte, error)
+	ServiceGetConsumption(from, to time.Time, threshold float64) ([]byte, error)
+	ServiceGetComponentSales(from, to time.Time) ([]byte, error)
 }
 
 type aggregationsService struct {
-	menuRepo  dal.MenuRepository
-	orderRepo dal.OrderRepository
+	menuRepo      dal.MenuRepository
+	recipeRepo    dal.RecipeVersionRepository
+	orderRepo     dal.OrderRepository
+	inventoryRepo dal.InventoryRepository
+	movementRepo  dal.MovementRepository
+	unitRepo      dal.UnitRepository
 }
 
-func NewAggregationsService(menuRepo dal.MenuRepository, orderRepo dal.OrderRepository) AggregationsService {
+func NewAggregationsService(menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, orderRepo dal.OrderRepository, inventoryRepo dal.InventoryRepository, movementRepo dal.MovementRepository, unitRepo dal.UnitRepository) AggregationsService {
 	return &aggregationsService{
-		menuRepo:  menuRepo,
-		orderRepo: orderRepo,
+		menuRepo:      menuRepo,
+		recipeRepo:    recipeRepo,
+		orderRepo:     orderRepo,
+		inventoryRepo: inventoryRepo,
+		movementRepo:  movementRepo,
+		unitRepo:      unitRepo,
 	}
 }
 
@@ -34,23 +46,27 @@ func (a *aggregationsService) ServiceGetTotal() ([]byte, error) {
 		return nil, err
 	}
 
-	productQuantities := make(map[string]int)
-	for _, order := range orders {
-		for _, item := range order.Items {
-			productQuantities[item.ProductID] += item.Quantity
-		}
-	}
-
 	menuItems, err := a.menuRepo.GetMenu()
 	if err != nil {
 		return nil, err
 	}
+	menu := make(map[string]models.MenuItem)
+	for _, menuItem := range menuItems {
+		menu[menuItem.ID] = menuItem
+	}
 
+	// Refunded orders are not sales, loyalty discounts are taken off.
 	totalSaleCount := 0.0
-	for _, menuItem := range menuItems {
-		if q, ok := productQuantities[menuItem.ID]; ok {
-			totalSaleCount += menuItem.Price * float64(q)
+	for _, order := range orders {
+		if order.Status == "refunded" {
+			continue
+		}
+		for _, item := range order.Items {
+			if menuItem, ok := menu[item.ProductID]; ok || item.Price != nil {
+				totalSaleCount += orderPrice(item, menuItem) * float64(item.Quantity)
+			}
 		}
+		totalSaleCount -= order.Discount
 	}
 
 	totalSale := models.TotalSales{
@@ -113,3 +129,226 @@ func (a *aggregationsService) ServiceGetPopular() ([]byte, error) {
 
 	return jsonFile, nil
 }
+
+// Theoretical consumption comes from closed orders and their recipes, actual consumption
+// from the recorded stock movements (receipts, transfers and restocking adjustments excluded). Variance above the threshold
+// percentage is flagged, it usually means waste, theft or a wrong recipe.
+func (a *aggregationsService) ServiceGetConsumption(from, to time.Time, threshold float64) ([]byte, error) {
+	orders, err := a.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := loadRecipeBook(a.menuRepo, a.recipeRepo, a.inventoryRepo, a.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	theoretical := theoreticalUsage(orders, book, from, to)
+
+	movements, err := a.movementRepo.GetMovements()
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]float64)
+	for _, movement := range movements {
+		if !countsAsUsage(movement) || !inPeriod(movement.CreatedAt, from, to) {
+			continue
+		}
+		actual[movement.IngredientID] -= movement.Delta
+	}
+
+	report := models.ConsumptionReport{
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		Ingredients: []models.IngredientConsumption{},
+	}
+
+	ingredientIDs := make(map[string]bool)
+	for ingID := range theoretical {
+		ingredientIDs[ingID] = true
+	}
+	for ingID := range actual {
+		ingredientIDs[ingID] = true
+	}
+
+	for ingID := range ingredientIDs {
+		line := models.IngredientConsumption{
+			IngredientID: ingID,
+			Theoretical:  theoretical[ingID],
+			Actual:       actual[ingID],
+			Variance:     actual[ingID] - theoretical[ingID],
+		}
+		if item, ok := findInventoryItem(ingID, book.inventory); ok {
+			line.Unit = item.Unit
+		}
+		if line.Theoretical > 0 {
+			line.VariancePercent = math.Round(line.Variance/line.Theoretical*10000) / 100
+			line.Flagged = math.Abs(line.VariancePercent) > threshold
+		} else {
+			line.Flagged = line.Variance != 0
+		}
+		report.Ingredients = append(report.Ingredients, line)
+	}
+
+	sort.SliceStable(report.Ingredients, func(i, j int) bool {
+		vi, vj := math.Abs(report.Ingredients[i].Variance), math.Abs(report.Ingredients[j].Variance)
+		if vi != vj {
+			return vi > vj
+		}
+		return report.Ingredients[i].IngredientID < report.Ingredients[j].IngredientID
+	})
+
+	jsonFile, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+
+	return jsonFile, nil
+}
+
+// Sales of the orders closed in the period per menu item, servings sold inside bundles
+// are attributed to their components.
+func (a *aggregationsService) ServiceGetComponentSales(from, to time.Time) ([]byte, error) {
+	orders, err := a.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := loadRecipeBook(a.menuRepo, a.recipeRepo, a.inventoryRepo, a.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make(map[string]*models.ComponentSales)
+	line := func(productID string) *models.ComponentSales {
+		if sales[productID] == nil {
+			sales[productID] = &models.ComponentSales{ProductID: productID, Name: book.menu[productID].Name}
+		}
+		return sales[productID]
+	}
+
+	for _, order := range orders {
+		if order.Status != "closed" || !inPeriod(closedAt(order), from, to) {
+			continue
+		}
+		for _, item := range order.Items {
+			menuItem, ok := book.menu[item.ProductID]
+			if !ok {
+				slog.Warn("Closed order references unknown menu item", "order", order.ID, "product", item.ProductID)
+				continue
+			}
+			revenue := orderPrice(item, menuItem) * float64(item.Quantity)
+			if !isBundle(menuItem) {
+				line(item.ProductID).DirectQuantity += item.Quantity
+				line(item.ProductID).DirectRevenue += revenue
+				continue
+			}
+
+			createdAt, _ := time.Parse(time.RFC3339, order.CreatedAt)
+			bundle, _ := book.recipe(item.ProductID, item.RecipeVersion, createdAt)
+			components, err := book.components(bundle, item.Choices)
+			if err != nil {
+				slog.Warn("Failed to expand closed bundle", "order", order.ID, "product", item.ProductID, "error", err)
+				continue
+			}
+			weights := make([]float64, len(components))
+			total := 0.0
+			for i, component := range components {
+				weights[i] = book.menu[component.ProductID].Price * float64(component.Quantity)
+				total += weights[i]
+			}
+			for i, component := range components {
+				share := 1 / float64(len(components))
+				if total > 0 {
+					share = weights[i] / total
+				}
+				line(component.ProductID).BundleQuantity += component.Quantity * item.Quantity
+				line(component.ProductID).BundleRevenue += revenue * share
+			}
+		}
+	}
+
+	report := models.ComponentSalesReport{
+		From:  from.Format(time.RFC3339),
+		To:    to.Format(time.RFC3339),
+		Items: []models.ComponentSales{},
+	}
+	for _, sale := range sales {
+		sale.TotalQuantity = sale.DirectQuantity + sale.BundleQuantity
+		sale.DirectRevenue = round2(sale.DirectRevenue)
+		sale.BundleRevenue = round2(sale.BundleRevenue)
+		sale.TotalRevenue = round2(sale.DirectRevenue + sale.BundleRevenue)
+		report.Items = append(report.Items, *sale)
+	}
+
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].TotalQuantity != report.Items[j].TotalQuantity {
+			return report.Items[i].TotalQuantity > report.Items[j].TotalQuantity
+		}
+		return report.Items[i].ProductID < report.Items[j].ProductID
+	})
+
+	jsonFile, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+
+	return jsonFile, nil
+}
+
+// theoreticalUsage adds up the recipe quantities of the orders closed in the period, in inventory units.
+// Every order counts with the recipes it was placed with, refunded orders were made too.
+func theoreticalUsage(orders []models.Order, book recipeBook, from, to time.Time) map[string]float64 {
+	usage := make(map[string]float64)
+	for _, order := range orders {
+		if (order.Status != "closed" && order.Status != "refunded") || !inPeriod(closedAt(order), from, to) {
+			continue
+		}
+		createdAt, _ := time.Parse(time.RFC3339, order.CreatedAt)
+		for _, item := range order.Items {
+			required, err := book.orderItemRequirements(item, createdAt)
+			if err != nil {
+				slog.Warn("Failed to expand closed order item", "order", order.ID, "product", item.ProductID, "error", err)
+				continue
+			}
+			for ingID, qty := range required {
+				usage[ingID] += qty
+			}
+		}
+	}
+
+	return usage
+}
+
+// countsAsUsage tells whether a movement is consumption, stock that arrives or moves elsewhere is not.
+func countsAsUsage(movement models.InventoryMovement) bool {
+	switch movement.Reason {
+	case models.MovementReceipt, models.MovementTransfer:
+		return false
+	case models.MovementAdjustment:
+		// Stocktake corrections count both ways, stock found on the shelf was not used.
+		// Other adjustments that add stock are restocks, only the ones taking it off are usage.
+		return movement.Note == stocktakeNote || movement.Delta < 0
+	}
+	return true
+}
+
+// closedAt falls back to the creation time for orders closed before closed_at was recorded.
+func closedAt(order models.Order) string {
+	if order.ClosedAt != "" {
+		return order.ClosedAt
+	}
+	return order.CreatedAt
+}
+
+func inPeriod(timestamp string, from, to time.Time) bool {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+	return !t.Before(from) && t.Before(to)
+}
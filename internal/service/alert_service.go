@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/webhook"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type AlertService interface {
+	ServiceGetAlerts(status string) ([]byte, error)
+	ServiceAcknowledgeAlert(id string) error
+	ServiceResolveAlert(id string) error
+	CheckStock(ingredientIDs []string)
+}
+
+type alertService struct {
+	alertRepo     dal.AlertRepository
+	inventoryRepo dal.InventoryRepository
+	webhookURL    string
+	events        EventBus
+}
+
+func NewAlertService(alertRepo dal.AlertRepository, inventoryRepo dal.InventoryRepository, webhookURL string, events EventBus) AlertService {
+	return &alertService{
+		alertRepo:     alertRepo,
+		inventoryRepo: inventoryRepo,
+		webhookURL:    webhookURL,
+		events:        events,
+	}
+}
+
+// Retrieve alerts, newest first, optionally only those with the given status.
+func (a *alertService) ServiceGetAlerts(status string) ([]byte, error) {
+	alerts, err := a.alertRepo.GetAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []models.StockAlert{}
+	for i := len(alerts) - 1; i >= 0; i-- {
+		if status == "" || alerts[i].Status == status {
+			filtered = append(filtered, alerts[i])
+		}
+	}
+
+	jsonFile, err := json.MarshalIndent(filtered, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (a *alertService) ServiceAcknowledgeAlert(id string) error {
+	alert, err := a.alertRepo.GetAlertID(id)
+	if err != nil {
+		return err
+	}
+	if alert.Status != models.AlertOpen {
+		slog.Error("Failed to acknowledge alert", "error", myerrors.ErrAlertNotOpen, "status", alert.Status)
+		return myerrors.ErrAlertNotOpen
+	}
+
+	alert.Status = models.AlertAcknowledged
+	alert.AcknowledgedAt = time.Now().Format(time.RFC3339)
+	return a.alertRepo.UpdateAlert(id, alert)
+}
+
+func (a *alertService) ServiceResolveAlert(id string) error {
+	alert, err := a.alertRepo.GetAlertID(id)
+	if err != nil {
+		return err
+	}
+	if alert.Status == models.AlertResolved {
+		slog.Error("Failed to resolve alert", "error", myerrors.ErrAlertResolved)
+		return myerrors.ErrAlertResolved
+	}
+
+	alert.Status = models.AlertResolved
+	alert.ResolvedAt = time.Now().Format(time.RFC3339)
+	return a.alertRepo.UpdateAlert(id, alert)
+}
+
+// CheckStock compares the given ingredients with their minimum levels. An ingredient
+// that drops to or below its minimum opens an alert, one that is restocked above it
+// resolves the active alert. Failures are logged, they never undo the stock change.
+func (a *alertService) CheckStock(ingredientIDs []string) {
+	inventory, err := a.inventoryRepo.GetInventory()
+	if err != nil {
+		slog.Error("Failed to check stock levels", "error", err)
+		return
+	}
+
+	alerts, err := a.alertRepo.GetAlerts()
+	if err != nil {
+		slog.Error("Failed to check stock levels", "error", err)
+		return
+	}
+
+	active := make(map[string]models.StockAlert)
+	for _, alert := range alerts {
+		if alert.Status != models.AlertResolved {
+			active[alert.IngredientID] = alert
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, id := range ingredientIDs {
+		if checked[id] {
+			continue
+		}
+		checked[id] = true
+
+		item, ok := findInventoryItem(id, inventory)
+		if !ok {
+			continue
+		}
+		alert, isActive := active[id]
+		isLow := item.MinQuantity > 0 && item.Quantity <= item.MinQuantity
+
+		switch {
+		case isLow && !isActive:
+			alert = models.StockAlert{
+				ID:           uuid.NewID("alert"),
+				IngredientID: id,
+				Quantity:     item.Quantity,
+				MinQuantity:  item.MinQuantity,
+				Status:       models.AlertOpen,
+				CreatedAt:    time.Now().Format(time.RFC3339),
+			}
+			if err := a.alertRepo.CreateAlert(alert); err != nil {
+				slog.Error("Failed to create stock alert", "error", err, "ingredient", id)
+				continue
+			}
+			slog.Warn("Low stock", "event", "stock.low", "alert", alert.ID, "ingredient", id, "quantity", item.Quantity, "min_quantity", item.MinQuantity, "unit", item.Unit)
+			webhook.Notify(a.webhookURL, alert)
+			a.events.Publish(models.TopicInventory, models.EventStockLow, id, alert)
+		case !isLow && isActive:
+			alert.Status = models.AlertResolved
+			alert.Quantity = item.Quantity
+			alert.ResolvedAt = time.Now().Format(time.RFC3339)
+			if err := a.alertRepo.UpdateAlert(alert.ID, alert); err != nil {
+				slog.Error("Failed to resolve stock alert", "error", err, "ingredient", id)
+				continue
+			}
+			slog.Info("Stock recovered", "event", "stock.recovered", "alert", alert.ID, "ingredient", id, "quantity", item.Quantity, "min_quantity", item.MinQuantity)
+			webhook.Notify(a.webhookURL, alert)
+			a.events.Publish(models.TopicInventory, models.EventStockRecovered, id, alert)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CategoryService interface {
+	ServiceGetCategories() ([]byte, error)
+	ServiceGetCategoryID(id string) ([]byte, error)
+	ServiceCreateCategory(newCategory []byte) error
+	ServiceUpdateCategory(id string, newCategory []byte) error
+	ServiceDeleteCategory(id string) error
+}
+
+type categoryService struct {
+	categoryRepo dal.CategoryRepository
+	menuRepo     dal.MenuRepository
+	ruleRepo     dal.LoyaltyRuleRepository
+}
+
+func NewCategoryService(categoryRepo dal.CategoryRepository, menuRepo dal.MenuRepository, ruleRepo dal.LoyaltyRuleRepository) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		menuRepo:     menuRepo,
+		ruleRepo:     ruleRepo,
+	}
+}
+
+// Retrieve all categories in display order.
+func (c *categoryService) ServiceGetCategories() ([]byte, error) {
+	categories, err := c.categoryRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	sortCategories(categories)
+
+	jsonFile, err := json.MarshalIndent(categories, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (c *categoryService) ServiceGetCategoryID(id string) ([]byte, error) {
+	category, err := c.categoryRepo.GetCategoryID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(category, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (c *categoryService) ServiceCreateCategory(newCategory []byte) error {
+	category, err := decodeCategory(newCategory)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.categoryRepo.GetCategoryID(category.ID); err == nil {
+		slog.Error("Failed to create category", "error", myerrors.ErrIDExist)
+		return myerrors.ErrIDExist
+	}
+
+	return c.categoryRepo.CreateCategory(category)
+}
+
+func (c *categoryService) ServiceUpdateCategory(id string, newCategory []byte) error {
+	category, err := decodeCategory(newCategory)
+	if err != nil {
+		return err
+	}
+
+	category.ID = id
+	return c.categoryRepo.UpdateCategory(id, category)
+}
+
+// Delete a category that no menu item is assigned to and no stamps rule counts.
+func (c *categoryService) ServiceDeleteCategory(id string) error {
+	if _, err := c.categoryRepo.GetCategoryID(id); err != nil {
+		return err
+	}
+
+	menu, err := c.menuRepo.GetMenu()
+	if err != nil {
+		return err
+	}
+	for _, menuItem := range menu {
+		if menuItem.CategoryID == id {
+			slog.Error("Failed to delete category", "error", myerrors.ErrCategoryInUse, "product", menuItem.ID)
+			return myerrors.ErrCategoryInUse
+		}
+	}
+
+	rules, err := c.ruleRepo.GetLoyaltyRules()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Type == models.LoyaltyStamps && rule.CategoryID == id {
+			slog.Error("Failed to delete category", "error", myerrors.ErrCategoryInUse, "rule", rule.ID)
+			return myerrors.ErrCategoryInUse
+		}
+	}
+
+	return c.categoryRepo.DeleteCategory(id)
+}
+
+// decodeCategory unmarshals and validates a category, a category is active unless it says otherwise.
+func decodeCategory(data []byte) (models.Category, error) {
+	category := models.Category{Active: true}
+	if err := json.Unmarshal(data, &category); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.Category{}, myerrors.ErrFailUnmarshal
+	}
+
+	if err := validation.CheckCategory(category); err != nil {
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+func sortCategories(categories []models.Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].Name < categories[j].Name
+	})
+}
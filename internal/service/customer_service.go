@@ -0,0 +1,259 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type CustomerService interface {
+	ServiceGetCustomers() ([]byte, error)
+	ServiceGetCustomerID(id string) ([]byte, error)
+	ServiceCreateCustomer(newCustomer []byte) ([]byte, error)
+	ServiceUpdateCustomer(id string, newCustomer []byte) error
+	ServiceDeleteCustomer(id string) ([]byte, error)
+	ServiceGetCustomerOrders(id string) ([]byte, error)
+}
+
+type customerService struct {
+	customerRepo dal.CustomerRepository
+	orderRepo    dal.OrderRepository
+	cardRepo     dal.GiftCardRepository
+	loyaltyRepo  dal.LoyaltyTransactionRepository
+}
+
+func NewCustomerService(customerRepo dal.CustomerRepository, orderRepo dal.OrderRepository, cardRepo dal.GiftCardRepository, loyaltyRepo dal.LoyaltyTransactionRepository) CustomerService {
+	return &customerService{
+		customerRepo: customerRepo,
+		orderRepo:    orderRepo,
+		cardRepo:     cardRepo,
+		loyaltyRepo:  loyaltyRepo,
+	}
+}
+
+// Retrieve all customers by name.
+func (c *customerService) ServiceGetCustomers() ([]byte, error) {
+	customers, err := c.customerRepo.GetCustomers()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(customers, func(i, j int) bool {
+		return strings.ToLower(customers[i].Name) < strings.ToLower(customers[j].Name)
+	})
+
+	jsonFile, err := json.MarshalIndent(customers, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (c *customerService) ServiceGetCustomerID(id string) ([]byte, error) {
+	customer, err := c.customerRepo.GetCustomerID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(customer, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Create a customer, the ID is generated. The email and phone must not be another
+// customer's.
+func (c *customerService) ServiceCreateCustomer(newCustomer []byte) ([]byte, error) {
+	customer, err := decodeCustomer(newCustomer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkDuplicate(customer); err != nil {
+		return nil, err
+	}
+
+	customer.ID = uuid.NewID("customer")
+	customer.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := c.customerRepo.CreateCustomer(customer); err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(customer, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (c *customerService) ServiceUpdateCustomer(id string, newCustomer []byte) error {
+	customer, err := decodeCustomer(newCustomer)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.customerRepo.GetCustomerID(id)
+	if err != nil {
+		return err
+	}
+
+	customer.ID = current.ID
+	if err := c.checkDuplicate(customer); err != nil {
+		return err
+	}
+
+	customer.CreatedAt = current.CreatedAt
+	return c.customerRepo.UpdateCustomer(id, customer)
+}
+
+// Delete a customer without orders, gift cards or loyalty transactions, otherwise
+// those are returned as dependents.
+func (c *customerService) ServiceDeleteCustomer(id string) ([]byte, error) {
+	if _, err := c.customerRepo.GetCustomerID(id); err != nil {
+		return nil, err
+	}
+
+	orders, err := c.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := c.cardRepo.GetGiftCards()
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := c.loyaltyRepo.GetLoyaltyTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []models.Dependent
+	for _, order := range orders {
+		if order.CustomerID == id {
+			dependents = append(dependents, models.Dependent{Type: dependentOrder, ID: order.ID, Name: order.CustomerName})
+		}
+	}
+	// Store credit and the loyalty log belong to the customer, void cards too as a
+	// refund to one issues the customer a new card.
+	for _, card := range cards {
+		if card.CustomerID == id {
+			dependents = append(dependents, models.Dependent{Type: dependentGiftCard, ID: card.Code})
+		}
+	}
+	for _, transaction := range transactions {
+		if transaction.CustomerID == id {
+			dependents = append(dependents, models.Dependent{Type: dependentLoyalty, ID: transaction.ID})
+		}
+	}
+	sortDependents(dependents)
+
+	if len(dependents) > 0 {
+		return refuseDelete(id, dependents)
+	}
+	return nil, c.customerRepo.DeleteCustomer(id)
+}
+
+// Retrieve the orders of a customer, newest first, with the lifetime spend and the
+// number of visits. Archived orders are left out.
+func (c *customerService) ServiceGetCustomerOrders(id string) ([]byte, error) {
+	customer, err := c.customerRepo.GetCustomerID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := c.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	history := models.CustomerOrders{
+		CustomerID: customer.ID,
+		Name:       customer.Name,
+		Orders:     []models.Order{},
+	}
+	for _, order := range orders {
+		if order.CustomerID != id || order.DeletedAt != "" {
+			continue
+		}
+		history.Orders = append(history.Orders, order)
+		history.Visits++
+		if order.Status == "closed" {
+			history.LifetimeSpend += order.Total
+		}
+		if order.CreatedAt > history.LastVisit {
+			history.LastVisit = order.CreatedAt
+		}
+	}
+	history.LifetimeSpend = round2(history.LifetimeSpend)
+	sort.SliceStable(history.Orders, func(i, j int) bool {
+		return history.Orders[i].CreatedAt > history.Orders[j].CreatedAt
+	})
+
+	jsonFile, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// checkDuplicate refuses a customer whose email or phone another customer already has.
+// Emails are compared without case, phones by their digits only.
+func (c *customerService) checkDuplicate(customer models.Customer) error {
+	customers, err := c.customerRepo.GetCustomers()
+	if err != nil {
+		return err
+	}
+
+	email, phone := normalizeEmail(customer.Email), normalizePhone(customer.Phone)
+	for _, other := range customers {
+		if other.ID == customer.ID {
+			continue
+		}
+		if (email != "" && normalizeEmail(other.Email) == email) || (phone != "" && normalizePhone(other.Phone) == phone) {
+			slog.Error("Failed to save customer", "error", myerrors.ErrDuplicateCustomer, "customer", other.ID)
+			return myerrors.ErrDuplicateCustomer
+		}
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+func decodeCustomer(data []byte) (models.Customer, error) {
+	var customer models.Customer
+	if err := json.Unmarshal(data, &customer); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.Customer{}, myerrors.ErrFailUnmarshal
+	}
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Email = strings.TrimSpace(customer.Email)
+
+	if err := validation.CheckCustomer(customer); err != nil {
+		return models.Customer{}, err
+	}
+	return customer, nil
+}
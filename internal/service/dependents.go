@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// Dependent types listed when a delete is refused.
+const (
+	dependentMenuItem      = "menu_item"
+	dependentSupplier      = "supplier"
+	dependentOrder         = "order"
+	dependentLot           = "lot"
+	dependentPurchaseOrder = "purchase_order"
+	dependentStocktake     = "stocktake"
+	dependentGiftCard      = "gift_card"
+	dependentLoyalty       = "loyalty_transaction"
+)
+
+// refuseDelete returns the dependents that keep a row from being deleted together
+// with ErrHasDependents.
+func refuseDelete(id string, dependents []models.Dependent) ([]byte, error) {
+	slog.Error("Failed to delete", "error", myerrors.ErrHasDependents, "id", id, "dependents", len(dependents))
+
+	conflict := models.DeleteConflict{
+		ErrorDescription: "Failed to delete " + id + ": " + myerrors.ErrHasDependents.Error(),
+		Dependents:       dependents,
+	}
+	jsonFile, err := json.MarshalIndent(conflict, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, myerrors.ErrHasDependents
+}
+
+func sortDependents(dependents []models.Dependent) {
+	sort.SliceStable(dependents, func(i, j int) bool {
+		if dependents[i].Type != dependents[j].Type {
+			return dependents[i].Type < dependents[j].Type
+		}
+		return dependents[i].ID < dependents[j].ID
+	})
+}
+
+func usesIngredient(menuItem models.MenuItem, ingredientID string) bool {
+	for _, ingredient := range menuItem.Ingredients {
+		if ingredient.IngredientID == ingredientID {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutIngredient returns the recipe ingredients other than ingredientID.
+func withoutIngredient(ingredients []models.MenuItemIngredient, ingredientID string) []models.MenuItemIngredient {
+	result := []models.MenuItemIngredient{}
+	for _, ingredient := range ingredients {
+		if ingredient.IngredientID != ingredientID {
+			result = append(result, ingredient)
+		}
+	}
+	return result
+}
+
+// orderUsesProduct tells whether an order has a line that uses productID, see lineUsesProduct.
+func orderUsesProduct(order models.Order, productID string) bool {
+	for _, item := range order.Items {
+		if lineUsesProduct(item, productID) {
+			return true
+		}
+	}
+	return false
+}
+
+// lineUsesProduct tells whether an order line is productID or a bundle with productID
+// chosen for a slot.
+func lineUsesProduct(item models.OrderItem, productID string) bool {
+	if item.ProductID == productID {
+		return true
+	}
+	for _, choice := range item.Choices {
+		if choice.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"hot-coffee/models"
+	"slices"
+	"sort"
+)
+
+// dietary returns the allergens and nutrition of one serving of a menu item from the
+// ingredients in its recipe. A bundle adds up its components; as the customer may pick
+// any option of a slot, every option counts for the allergens and the option with the
+// most calories for the nutrition.
+func (b recipeBook) dietary(menuItem models.MenuItem) ([]string, models.Nutrition) {
+	allergens := make(map[string]bool)
+	var nutrition models.Nutrition
+
+	// Recipes whose units do not convert are rejected when saved, they count as empty.
+	required, _ := b.requirements(menuItem, 1)
+	for ingID, qty := range required {
+		item, ok := findInventoryItem(ingID, b.inventory)
+		if !ok {
+			continue
+		}
+		for _, allergen := range item.Allergens {
+			allergens[allergen] = true
+		}
+		if item.Nutrition != nil {
+			addNutrition(&nutrition, *item.Nutrition, qty)
+		}
+	}
+
+	add := func(productID string, quantity int) models.Nutrition {
+		component, ok := b.menu[productID]
+		if !ok || productID == menuItem.ID {
+			return models.Nutrition{}
+		}
+		componentAllergens, componentNutrition := b.dietary(component)
+		for _, allergen := range componentAllergens {
+			allergens[allergen] = true
+		}
+		var total models.Nutrition
+		addNutrition(&total, componentNutrition, float64(quantity))
+		return total
+	}
+
+	for _, component := range menuItem.Components {
+		addNutrition(&nutrition, add(component.ProductID, component.Quantity), 1)
+	}
+	for _, slot := range menuItem.Slots {
+		var most models.Nutrition
+		for i, option := range slot.Options {
+			if optionNutrition := add(option, slot.Quantity); i == 0 || optionNutrition.Calories > most.Calories {
+				most = optionNutrition
+			}
+		}
+		addNutrition(&nutrition, most, 1)
+	}
+
+	list := make([]string, 0, len(allergens))
+	for allergen := range allergens {
+		list = append(list, allergen)
+	}
+	sort.Strings(list)
+
+	return list, roundNutrition(nutrition)
+}
+
+// containsAllergen tells whether any of the allergens is among the excluded ones.
+func containsAllergen(allergens, excluded []string) bool {
+	for _, allergen := range allergens {
+		if slices.Contains(excluded, allergen) {
+			return true
+		}
+	}
+	return false
+}
+
+func addNutrition(total *models.Nutrition, n models.Nutrition, factor float64) {
+	total.Calories += n.Calories * factor
+	total.Fat += n.Fat * factor
+	total.Carbohydrates += n.Carbohydrates * factor
+	total.Sugar += n.Sugar * factor
+	total.Protein += n.Protein * factor
+	total.Salt += n.Salt * factor
+}
+
+func roundNutrition(n models.Nutrition) models.Nutrition {
+	return models.Nutrition{
+		Calories:      round2(n.Calories),
+		Fat:           round2(n.Fat),
+		Carbohydrates: round2(n.Carbohydrates),
+		Sugar:         round2(n.Sugar),
+		Protein:       round2(n.Protein),
+		Salt:          round2(n.Salt),
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/models"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventBus hands the events published by the services to the subscribers of their
+// topic. The latest events are kept in a bounded buffer so subscribers that lost
+// their connection can catch up.
+type EventBus interface {
+	Publish(topic, eventType, subject string, data any)
+	// Subscribe returns the buffered events after lastID and the channel of new ones.
+	// complete is false when events after lastID already left the buffer. The channel
+	// is closed when the subscriber falls too far behind, it has to subscribe again
+	// from the last event it got. cancel must be called once the subscriber is gone.
+	Subscribe(topics []string, lastID int64) (missed []models.Event, complete bool, events <-chan models.Event, cancel func())
+	// Listen registers a function that is handed every event as it is published, in
+	// order. It runs while Publish holds the bus, so it must not block.
+	Listen(listener func(models.Event))
+}
+
+type eventBus struct {
+	mu          sync.Mutex
+	lastID      int64
+	size        int
+	buffer      []models.Event
+	subscribers map[*eventSubscriber]bool
+	listeners   []func(models.Event)
+}
+
+type eventSubscriber struct {
+	topics []string
+	events chan models.Event
+}
+
+func NewEventBus(size int) EventBus {
+	return &eventBus{
+		size:        size,
+		subscribers: make(map[*eventSubscriber]bool),
+	}
+}
+
+// Publish stores the event and passes it on, the data is marshalled right away so
+// later changes to it do not show. Subscribers too slow to keep up are dropped, so
+// they resubscribe and catch up from the buffer instead of missing events.
+func (b *eventBus) Publish(topic, eventType, subject string, data any) {
+	var raw json.RawMessage
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			slog.Error("Failed to marshal event", "error", err, "type", eventType)
+			return
+		}
+		raw = encoded
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := models.Event{
+		ID:        b.lastID,
+		Topic:     topic,
+		Type:      eventType,
+		Subject:   subject,
+		Data:      raw,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > b.size {
+		b.buffer = slices.Delete(b.buffer, 0, len(b.buffer)-b.size)
+	}
+
+	for _, listener := range b.listeners {
+		listener(event)
+	}
+
+	for subscriber := range b.subscribers {
+		if !subscriber.wants(topic) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			slog.Warn("Event subscriber is too slow, subscription closed", "id", event.ID, "type", eventType)
+			delete(b.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+func (b *eventBus) Subscribe(topics []string, lastID int64) ([]models.Event, bool, <-chan models.Event, func()) {
+	subscriber := &eventSubscriber{topics: topics, events: make(chan models.Event, 64)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[subscriber] = true
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, subscriber)
+	}
+
+	if lastID <= 0 {
+		return nil, true, subscriber.events, cancel
+	}
+
+	// IDs restart with the server, an ID ahead of the last one is from an older run.
+	complete := lastID <= b.lastID
+	if len(b.buffer) > 0 && b.buffer[0].ID > lastID+1 {
+		complete = false
+	}
+	var missed []models.Event
+	for _, event := range b.buffer {
+		if event.ID > lastID && subscriber.wants(event.Topic) {
+			missed = append(missed, event)
+		}
+	}
+	return missed, complete, subscriber.events, cancel
+}
+
+func (b *eventBus) Listen(listener func(models.Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, listener)
+}
+
+func (s *eventSubscriber) wants(topic string) bool {
+	return len(s.topics) == 0 || slices.Contains(s.topics, topic)
+}
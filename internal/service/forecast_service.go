@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// A run-out time is only projected this many days ahead, further out it is meaningless
+// and would overflow a time.Duration.
+const maxRunOutDays = 3650
+
+type ForecastService interface {
+	ServiceGetForecast(lookbackDays int, leadTime, targetCover float64) ([]byte, error)
+}
+
+type forecastService struct {
+	orderRepo         dal.OrderRepository
+	menuRepo          dal.MenuRepository
+	recipeRepo        dal.RecipeVersionRepository
+	inventoryRepo     dal.InventoryRepository
+	unitRepo          dal.UnitRepository
+	purchaseOrderRepo dal.PurchaseOrderRepository
+	supplierRepo      dal.SupplierRepository
+}
+
+func NewForecastService(orderRepo dal.OrderRepository, menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, inventoryRepo dal.InventoryRepository, unitRepo dal.UnitRepository, purchaseOrderRepo dal.PurchaseOrderRepository, supplierRepo dal.SupplierRepository) ForecastService {
+	return &forecastService{
+		orderRepo:         orderRepo,
+		menuRepo:          menuRepo,
+		recipeRepo:        recipeRepo,
+		inventoryRepo:     inventoryRepo,
+		unitRepo:          unitRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+	}
+}
+
+// The average daily usage comes from the recipes of the orders closed during the last
+// lookbackDays days. An ingredient should be reordered once its stock and what is
+// already on order fall to the usage over the lead time plus its minimum level; the
+// suggestion tops it up to cover the lead time and the target cover, rounded up to
+// whole packs of the cheapest supplier.
+func (f *forecastService) ServiceGetForecast(lookbackDays int, leadTime, targetCover float64) ([]byte, error) {
+	orders, err := f.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := loadRecipeBook(f.menuRepo, f.recipeRepo, f.inventoryRepo, f.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	onOrder, err := f.onOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	suppliers, err := f.supplierRepo.GetSuppliers()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	usage := theoreticalUsage(orders, book, now.AddDate(0, 0, -lookbackDays), now)
+
+	forecast := models.StockForecast{
+		GeneratedAt:     now.Format(time.RFC3339),
+		LookbackDays:    lookbackDays,
+		LeadTimeDays:    leadTime,
+		TargetCoverDays: targetCover,
+		Ingredients:     []models.IngredientForecast{},
+	}
+
+	for _, item := range book.inventory {
+		if item.DeletedAt != "" {
+			continue
+		}
+		daily := usage[item.IngredientID] / float64(lookbackDays)
+		line := models.IngredientForecast{
+			IngredientID:      item.IngredientID,
+			Unit:              item.Unit,
+			Quantity:          item.Quantity,
+			OnOrder:           onOrder[item.IngredientID],
+			AverageDailyUsage: round2(daily),
+			ReorderPoint:      round2(daily*leadTime + item.MinQuantity),
+		}
+
+		if daily > 0 {
+			cover := round2(item.Quantity / daily)
+			line.DaysOfCover = &cover
+			if days := item.Quantity / daily; days <= maxRunOutDays {
+				line.RunsOutAt = now.Add(time.Duration(days * float64(24*time.Hour))).Format(time.RFC3339)
+			}
+		}
+
+		available := item.Quantity + line.OnOrder
+		line.ReorderNow = available <= line.ReorderPoint && (daily > 0 || item.MinQuantity > 0)
+		if line.ReorderNow {
+			line.SuggestedQuantity = round2(math.Max(0, daily*(leadTime+targetCover)+item.MinQuantity-available))
+		}
+
+		if supplier, supplied, ok := cheapestSupplier(suppliers, item.IngredientID); ok && line.SuggestedQuantity > 0 {
+			line.SupplierID = supplier.ID
+			line.SuggestedPacks = math.Ceil(line.SuggestedQuantity / supplied.PackSize)
+		}
+
+		forecast.Ingredients = append(forecast.Ingredients, line)
+	}
+
+	sort.SliceStable(forecast.Ingredients, func(i, j int) bool {
+		ci, cj := forecast.Ingredients[i].DaysOfCover, forecast.Ingredients[j].DaysOfCover
+		if (ci == nil) != (cj == nil) {
+			return cj == nil
+		}
+		if ci != nil && *ci != *cj {
+			return *ci < *cj
+		}
+		return forecast.Ingredients[i].IngredientID < forecast.Ingredients[j].IngredientID
+	})
+
+	jsonFile, err := json.MarshalIndent(forecast, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+
+	return jsonFile, nil
+}
+
+// onOrder adds up what sent purchase orders still have to deliver per ingredient.
+func (f *forecastService) onOrder() (map[string]float64, error) {
+	purchaseOrders, err := f.purchaseOrderRepo.GetPurchaseOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	onOrder := make(map[string]float64)
+	for _, purchaseOrder := range purchaseOrders {
+		if purchaseOrder.Status != models.PurchaseOrderSent && purchaseOrder.Status != models.PurchaseOrderPartiallyReceived {
+			continue
+		}
+		for _, line := range purchaseOrder.Lines {
+			onOrder[line.IngredientID] += (line.Packs - line.ReceivedPacks) * line.PackSize
+		}
+	}
+
+	return onOrder, nil
+}
+
+// cheapestSupplier picks the supplier with the lowest price per unit of an ingredient.
+func cheapestSupplier(suppliers []models.Supplier, ingredientID string) (models.Supplier, models.SupplierItem, bool) {
+	var best models.Supplier
+	var bestItem models.SupplierItem
+	found := false
+	for _, supplier := range suppliers {
+		item, ok := supplierItem(supplier, ingredientID)
+		if !ok || item.PackSize <= 0 {
+			continue
+		}
+		if !found || item.Price/item.PackSize < bestItem.Price/bestItem.PackSize {
+			best, bestItem, found = supplier, item, true
+		}
+	}
+	return best, bestItem, found
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"crypto/rand"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Letters and digits that cannot be mistaken for each other when read out.
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newGiftCardCode returns a random code like "GC-7KQM-2XHD-P9TA". Codes are spent
+// like cash, so they come from crypto/rand.
+func newGiftCardCode() (string, error) {
+	groups := make([]string, 3)
+	for i := range groups {
+		group := make([]byte, 4)
+		for j := range group {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(giftCardAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			group[j] = giftCardAlphabet[n.Int64()]
+		}
+		groups[i] = string(group)
+	}
+	return "GC-" + strings.Join(groups, "-"), nil
+}
+
+// giftCardEntry moves amount on or off a card and returns the transaction to log.
+func giftCardEntry(card *models.GiftCard, kind string, amount float64, orderID, note string, now time.Time) models.GiftCardTransaction {
+	card.Balance = round2(card.Balance + amount)
+	return models.GiftCardTransaction{
+		ID:        uuid.NewID("giftcard"),
+		Code:      card.Code,
+		Type:      kind,
+		Amount:    round2(amount),
+		Balance:   card.Balance,
+		OrderID:   orderID,
+		Note:      note,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+}
+
+func saveGiftCardEntry(cardRepo dal.GiftCardRepository, transactionRepo dal.GiftCardTransactionRepository, card models.GiftCard, entry models.GiftCardTransaction) error {
+	if err := cardRepo.UpdateGiftCard(card.Code, card); err != nil {
+		return err
+	}
+	return transactionRepo.CreateGiftCardTransaction(entry)
+}
+
+// refundGiftCards puts what was paid on an order with gift cards back on the cards. A
+// card that was voided since cannot be spent any more, its share goes on a new card
+// for the same customer. The caller holds the orders lock.
+func refundGiftCards(cardRepo dal.GiftCardRepository, transactionRepo dal.GiftCardTransactionRepository, order models.Order, now time.Time) error {
+	for _, payment := range order.GiftCards {
+		card, err := cardRepo.GetGiftCardID(payment.Code)
+		if err != nil {
+			return err
+		}
+		if card.Status != models.GiftCardVoid {
+			entry := giftCardEntry(&card, models.GiftCardRefund, payment.Amount, order.ID, "", now)
+			if err := saveGiftCardEntry(cardRepo, transactionRepo, card, entry); err != nil {
+				return err
+			}
+			continue
+		}
+
+		code, err := newGiftCardCode()
+		if err != nil {
+			slog.Error("Failed to generate gift card code", "error", err)
+			return err
+		}
+		replacement := models.GiftCard{
+			Code:       code,
+			Status:     models.GiftCardActive,
+			CustomerID: card.CustomerID,
+			CreatedAt:  now.Format(time.RFC3339),
+		}
+		entry := giftCardEntry(&replacement, models.GiftCardRefund, payment.Amount, order.ID, "refund for void card "+card.Code, now)
+		if err := cardRepo.CreateGiftCard(replacement); err != nil {
+			return err
+		}
+		if err := transactionRepo.CreateGiftCardTransaction(entry); err != nil {
+			return err
+		}
+		slog.Info("Refunded onto a new gift card", "order", order.ID, "void", card.Code, "code", replacement.Code, "amount", payment.Amount)
+	}
+	return nil
+}
+
+// giftCardPaid adds up what was paid on an order with gift cards.
+func giftCardPaid(order models.Order) float64 {
+	paid := 0.0
+	for _, payment := range order.GiftCards {
+		paid += payment.Amount
+	}
+	return round2(paid)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type GiftCardService interface {
+	ServiceGetGiftCards() ([]byte, error)
+	ServiceGetGiftCard(code string) ([]byte, error)
+	ServiceIssueGiftCard(operation []byte) ([]byte, error)
+	ServiceTopUpGiftCard(code string, operation []byte) ([]byte, error)
+	ServiceRedeemGiftCard(code string, operation []byte) ([]byte, error)
+	ServiceVoidGiftCard(code string) ([]byte, error)
+	ServicePayOrder(orderID string, operation []byte) ([]byte, error)
+}
+
+type giftCardService struct {
+	cardRepo        dal.GiftCardRepository
+	transactionRepo dal.GiftCardTransactionRepository
+	customerRepo    dal.CustomerRepository
+	orderRepo       dal.OrderRepository
+	// orders serializes balance and order changes with the order service, which
+	// gives gift card payments back.
+	orders OrderService
+	events EventBus
+}
+
+func NewGiftCardService(cardRepo dal.GiftCardRepository, transactionRepo dal.GiftCardTransactionRepository, customerRepo dal.CustomerRepository, orderRepo dal.OrderRepository, orders OrderService, events EventBus) GiftCardService {
+	return &giftCardService{
+		cardRepo:        cardRepo,
+		transactionRepo: transactionRepo,
+		customerRepo:    customerRepo,
+		orderRepo:       orderRepo,
+		orders:          orders,
+		events:          events,
+	}
+}
+
+// Retrieve all gift cards, newest first.
+func (g *giftCardService) ServiceGetGiftCards() ([]byte, error) {
+	cards, err := g.cardRepo.GetGiftCards()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(cards, func(i, j int) bool {
+		return cards[i].CreatedAt > cards[j].CreatedAt
+	})
+
+	jsonFile, err := json.MarshalIndent(cards, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Retrieve a gift card with its balance and transactions.
+func (g *giftCardService) ServiceGetGiftCard(code string) ([]byte, error) {
+	card, err := g.cardRepo.GetGiftCardID(code)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := g.transactionRepo.GetGiftCardTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	history := models.GiftCardHistory{GiftCard: card, Transactions: []models.GiftCardTransaction{}}
+	for _, transaction := range transactions {
+		if transaction.Code == code {
+			history.Transactions = append(history.Transactions, transaction)
+		}
+	}
+
+	jsonFile, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Issue a gift card with a generated code and the amount as its first balance, a card
+// issued to a customer is the customer's store credit.
+func (g *giftCardService) ServiceIssueGiftCard(operation []byte) ([]byte, error) {
+	issue, err := decodeGiftCardOperation(operation)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.CheckAmount(issue.Amount); err != nil {
+		return nil, err
+	}
+
+	if issue.CustomerID != "" {
+		if _, err := g.customerRepo.GetCustomerID(issue.CustomerID); err == myerrors.ErrNotFound {
+			slog.Error("Failed to issue gift card", "error", myerrors.ErrUnknownCustomer, "customer", issue.CustomerID)
+			return nil, myerrors.ErrUnknownCustomer
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := newGiftCardCode()
+	if err != nil {
+		slog.Error("Failed to generate gift card code", "error", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	card := models.GiftCard{
+		Code:       code,
+		Status:     models.GiftCardActive,
+		CustomerID: issue.CustomerID,
+		CreatedAt:  now.Format(time.RFC3339),
+	}
+	entry := giftCardEntry(&card, models.GiftCardIssue, issue.Amount, "", issue.Note, now)
+	if err := g.cardRepo.CreateGiftCard(card); err != nil {
+		return nil, err
+	}
+	if err := g.transactionRepo.CreateGiftCardTransaction(entry); err != nil {
+		return nil, err
+	}
+
+	return marshalGiftCard(card)
+}
+
+// Put money on an active gift card.
+func (g *giftCardService) ServiceTopUpGiftCard(code string, operation []byte) ([]byte, error) {
+	topUp, err := decodeGiftCardOperation(operation)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.CheckAmount(topUp.Amount); err != nil {
+		return nil, err
+	}
+
+	var card models.GiftCard
+	err = g.orders.UpdateOrders(func() error {
+		card, err = g.activeCard(code)
+		if err != nil {
+			return err
+		}
+
+		entry := giftCardEntry(&card, models.GiftCardTopUp, topUp.Amount, "", topUp.Note, time.Now())
+		return saveGiftCardEntry(g.cardRepo, g.transactionRepo, card, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalGiftCard(card)
+}
+
+// Take money off an active gift card, outside of an order.
+func (g *giftCardService) ServiceRedeemGiftCard(code string, operation []byte) ([]byte, error) {
+	redeem, err := decodeGiftCardOperation(operation)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.CheckAmount(redeem.Amount); err != nil {
+		return nil, err
+	}
+
+	var card models.GiftCard
+	err = g.orders.UpdateOrders(func() error {
+		card, err = g.activeCard(code)
+		if err != nil {
+			return err
+		}
+		if redeem.Amount > card.Balance {
+			slog.Error("Failed to redeem gift card", "error", myerrors.ErrInsufficientFunds, "code", code, "balance", card.Balance)
+			return myerrors.ErrInsufficientFunds
+		}
+
+		entry := giftCardEntry(&card, models.GiftCardRedeem, -redeem.Amount, "", redeem.Note, time.Now())
+		return saveGiftCardEntry(g.cardRepo, g.transactionRepo, card, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalGiftCard(card)
+}
+
+// Void a gift card, what is left on it is written off.
+func (g *giftCardService) ServiceVoidGiftCard(code string) ([]byte, error) {
+	var card models.GiftCard
+	err := g.orders.UpdateOrders(func() error {
+		var err error
+		card, err = g.activeCard(code)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		card.Status = models.GiftCardVoid
+		card.VoidedAt = now.Format(time.RFC3339)
+		entry := giftCardEntry(&card, models.GiftCardVoided, -card.Balance, "", "", now)
+		return saveGiftCardEntry(g.cardRepo, g.transactionRepo, card, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalGiftCard(card)
+}
+
+// Pay all or part of an order with a gift card. Without an amount the card pays what
+// is left of the order total, as far as its balance goes.
+func (g *giftCardService) ServicePayOrder(orderID string, operation []byte) ([]byte, error) {
+	payment, err := decodeGiftCardOperation(operation)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Code == "" {
+		slog.Error("Validation failed: Gift card code is required")
+		return nil, myerrors.ErrIdRequired
+	}
+	if payment.Amount != 0 {
+		if err := validation.CheckAmount(payment.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	// The order is read under the lock too, so two payments cannot both pay what is due.
+	var order models.Order
+	err = g.orders.UpdateOrders(func() error {
+		order, err = g.orderRepo.GetOrderID(orderID)
+		if err != nil {
+			return err
+		}
+		if order.DeletedAt != "" {
+			slog.Error("Failed to pay order", "error", myerrors.ErrArchived, "id", orderID)
+			return myerrors.ErrArchived
+		}
+		if order.Status == "refunded" {
+			slog.Error("Failed to pay order", "error", myerrors.ErrOrderRefunded, "id", orderID)
+			return myerrors.ErrOrderRefunded
+		}
+
+		due := round2(order.Total - giftCardPaid(order))
+		if due <= 0 {
+			slog.Error("Failed to pay order", "error", myerrors.ErrOrderPaid, "id", orderID)
+			return myerrors.ErrOrderPaid
+		}
+		if payment.Amount > due {
+			slog.Error("Failed to pay order", "error", myerrors.ErrOverpayment, "id", orderID, "due", due)
+			return myerrors.ErrOverpayment
+		}
+
+		card, err := g.activeCard(payment.Code)
+		if err != nil {
+			return err
+		}
+
+		amount := payment.Amount
+		if amount == 0 {
+			amount = math.Min(due, card.Balance)
+		}
+		if amount <= 0 || amount > card.Balance {
+			slog.Error("Failed to pay order", "error", myerrors.ErrInsufficientFunds, "code", card.Code, "balance", card.Balance)
+			return myerrors.ErrInsufficientFunds
+		}
+
+		entry := giftCardEntry(&card, models.GiftCardRedeem, -amount, order.ID, payment.Note, time.Now())
+		if err := saveGiftCardEntry(g.cardRepo, g.transactionRepo, card, entry); err != nil {
+			return err
+		}
+
+		order.GiftCards = append(order.GiftCards, models.GiftCardPayment{Code: card.Code, Amount: round2(amount)})
+		return g.orderRepo.UpdateOrder(order.ID, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.events.Publish(models.TopicOrders, models.EventOrderUpdated, order.ID, order)
+
+	jsonFile, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// activeCard retrieves a card that is not void.
+func (g *giftCardService) activeCard(code string) (models.GiftCard, error) {
+	card, err := g.cardRepo.GetGiftCardID(code)
+	if err != nil {
+		return models.GiftCard{}, err
+	}
+	if card.Status == models.GiftCardVoid {
+		slog.Error("Failed to use gift card", "error", myerrors.ErrGiftCardVoid, "code", code)
+		return models.GiftCard{}, myerrors.ErrGiftCardVoid
+	}
+	return card, nil
+}
+
+func decodeGiftCardOperation(data []byte) (models.GiftCardOperation, error) {
+	var operation models.GiftCardOperation
+	if len(data) == 0 {
+		return operation, nil
+	}
+	if err := json.Unmarshal(data, &operation); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.GiftCardOperation{}, myerrors.ErrFailUnmarshal
+	}
+	return operation, nil
+}
+
+func marshalGiftCard(card models.GiftCard) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(card, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
@@ -3,35 +3,75 @@ package service
 import (
 	"encoding/json"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/csvencoder"
+	"hot-coffee/internal/utils/units"
 	"hot-coffee/internal/utils/validation"
 	"hot-coffee/models"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
 
 type InventoryService interface {
-	ServiceGetInventory() ([]byte, error)
+	ServiceGetInventory(includeArchived bool) ([]byte, error)
 	ServiceGetInventoryID(id string) ([]byte, error)
 	ServiceCreateInventory(newInventoryItem []byte) error
 	ServiceUpdateInventory(id string, newInventoryItem []byte) error
-	ServiceDeleteInventory(id string) error
+	ServiceDeleteInventory(id string, cascade bool) ([]byte, error)
+	ServiceRestoreInventory(id string) error
+	ServiceImportInventory(data []byte, isCSV, dryRun, upsert bool) ([]byte, error)
+	ServiceAdjustInventory(id string, adjustment []byte) error
+	ServiceGetMovements(id string, reason string) ([]byte, error)
 }
 
 type inventoryService struct {
-	repo dal.InventoryRepository
+	repo              dal.InventoryRepository
+	movementRepo      dal.MovementRepository
+	menuRepo          dal.MenuRepository
+	recipeRepo        dal.RecipeVersionRepository
+	supplierRepo      dal.SupplierRepository
+	unitRepo          dal.UnitRepository
+	lotRepo           dal.LotRepository
+	purchaseOrderRepo dal.PurchaseOrderRepository
+	stocktakeRepo     dal.StocktakeRepository
+	ledger            StockLedger
+	events            EventBus
 }
 
-func NewInventoryService(repo dal.InventoryRepository) InventoryService {
-	return &inventoryService{repo: repo}
+func NewInventoryService(repo dal.InventoryRepository, movementRepo dal.MovementRepository, menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, supplierRepo dal.SupplierRepository, unitRepo dal.UnitRepository, lotRepo dal.LotRepository, purchaseOrderRepo dal.PurchaseOrderRepository, stocktakeRepo dal.StocktakeRepository, ledger StockLedger, events EventBus) InventoryService {
+	return &inventoryService{
+		repo:              repo,
+		movementRepo:      movementRepo,
+		menuRepo:          menuRepo,
+		recipeRepo:        recipeRepo,
+		supplierRepo:      supplierRepo,
+		unitRepo:          unitRepo,
+		lotRepo:           lotRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		stocktakeRepo:     stocktakeRepo,
+		ledger:            ledger,
+		events:            events,
+	}
 }
 
-func (i *inventoryService) ServiceGetInventory() ([]byte, error) {
-	inventoryStruct, err := i.repo.GetInventory()
+// Retrieve the inventory, archived items only when includeArchived is set.
+func (i *inventoryService) ServiceGetInventory(includeArchived bool) ([]byte, error) {
+	inventory, err := i.repo.GetInventory()
 	if err != nil {
 		return nil, err
 	}
 
+	inventoryStruct := []models.InventoryItem{}
+	for _, item := range inventory {
+		if item.DeletedAt == "" || includeArchived {
+			inventoryStruct = append(inventoryStruct, item)
+		}
+	}
+
 	jsonFile, err := json.MarshalIndent(inventoryStruct, "", "  ")
 	if err != nil {
 		slog.Error("Failed to marshal", "error", err)
@@ -63,18 +103,30 @@ func (i *inventoryService) ServiceCreateInventory(newInventoryItem []byte) error
 		slog.Error("Failed to unmarshal", "error", err)
 		return myerrors.ErrFailUnmarshal
 	}
+	inventory.DeletedAt = ""
 
 	if err := validation.CheckInventory(inventory); err != nil {
 		return err
 	}
 
-	checkInventoryID, _ := i.repo.GetInventoryID(inventory.IngredientID)
-	if checkInventoryID.IngredientID == inventory.IngredientID {
-		slog.Error("Failed to create inventory item", "error", myerrors.ErrIDExist)
-		return myerrors.ErrIDExist
-	}
+	return i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		checkInventoryID, _ := i.repo.GetInventoryID(inventory.IngredientID)
+		if checkInventoryID.IngredientID == inventory.IngredientID {
+			slog.Error("Failed to create inventory item", "error", myerrors.ErrIDExist)
+			return nil, myerrors.ErrIDExist
+		}
+
+		if err := i.repo.CreateInventory(inventory); err != nil {
+			return nil, err
+		}
+		i.events.Publish(models.TopicInventory, models.EventInventoryCreated, inventory.IngredientID, inventory)
 
-	return i.repo.CreateInventory(inventory)
+		return []models.InventoryMovement{{
+			IngredientID: inventory.IngredientID,
+			Delta:        inventory.Quantity,
+			Reason:       models.MovementReceipt,
+		}}, nil
+	})
 }
 
 func (i *inventoryService) ServiceUpdateInventory(id string, newInventoryItem []byte) error {
@@ -83,14 +135,533 @@ func (i *inventoryService) ServiceUpdateInventory(id string, newInventoryItem []
 		slog.Error("Failed to unmarshal", "error", err)
 		return myerrors.ErrFailUnmarshal
 	}
+	inventory.DeletedAt = ""
 
 	if err := validation.CheckInventory(inventory); err != nil {
 		return err
 	}
 
-	return i.repo.UpdateInventory(id, inventory)
+	return i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		current, err := i.repo.GetInventoryID(id)
+		if err != nil {
+			return nil, err
+		}
+		if current.DeletedAt != "" {
+			slog.Error("Failed to update inventory", "error", myerrors.ErrArchived, "ingredient", id)
+			return nil, myerrors.ErrArchived
+		}
+
+		if units.Normalize(current.Unit) != units.Normalize(inventory.Unit) {
+			if err := i.checkRecipeUnits(inventory); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := i.repo.UpdateInventory(id, inventory); err != nil {
+			return nil, err
+		}
+		i.events.Publish(models.TopicInventory, models.EventInventoryUpdated, id, inventory)
+
+		return []models.InventoryMovement{{
+			IngredientID: inventory.IngredientID,
+			Delta:        inventory.Quantity - current.Quantity,
+			Reason:       models.MovementAdjustment,
+		}}, nil
+	})
+}
+
+// checkRecipeUnits makes sure recipes that give the item an explicit unit can still be
+// converted after the item's unit changes.
+func (i *inventoryService) checkRecipeUnits(item models.InventoryItem) error {
+	menu, err := i.menuRepo.GetMenu()
+	if err != nil {
+		return err
+	}
+
+	customUnits, err := i.unitRepo.GetUnits()
+	if err != nil {
+		return err
+	}
+
+	for _, menuItem := range menu {
+		for _, ingredient := range menuItem.Ingredients {
+			if ingredient.IngredientID != item.IngredientID || ingredient.Unit == "" {
+				continue
+			}
+			if _, err := units.Convert(1, ingredient.Unit, item.Unit, customUnits); err != nil {
+				slog.Error("Failed to change unit", "error", err, "ingredient", item.IngredientID, "product", menuItem.ID)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Archive an ingredient that nothing uses any more. With cascade the ingredient is
+// first taken out of the recipes, recording new recipe versions, out of the supplier
+// catalogs, draft purchase orders and open stocktakes; otherwise they are returned as
+// dependents. Recipes of archived menu items keep it. Even with cascade it stays while
+// lots still hold its stock, sent purchase orders still expect it or a recipe would
+// be left without ingredients; only those are returned then.
+func (i *inventoryService) ServiceDeleteInventory(id string, cascade bool) ([]byte, error) {
+	// The check and the cascade run in one ledger step, so no lot, purchase order or
+	// stocktake of the ingredient changes in between.
+	var conflict []byte
+	err := i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		var err error
+		conflict, err = i.deleteInventory(id, cascade)
+		return nil, err
+	})
+	return conflict, err
+}
+
+// deleteInventory works out every change of the cascade before it writes any. The
+// dependents are written first and the ingredient is archived last, so a failed write
+// leaves it in use by fewer records, never archived while still in use.
+func (i *inventoryService) deleteInventory(id string, cascade bool) ([]byte, error) {
+	archived, err := i.repo.GetInventoryID(id)
+	if err != nil {
+		return nil, err
+	}
+	if archived.DeletedAt != "" {
+		slog.Error("Failed to delete inventory", "error", myerrors.ErrArchived, "ingredient", id)
+		return nil, myerrors.ErrArchived
+	}
+
+	book, err := loadRecipeBook(i.menuRepo, i.recipeRepo, i.repo, i.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	suppliers, err := i.supplierRepo.GetSuppliers()
+	if err != nil {
+		return nil, err
+	}
+
+	lots, err := i.lotRepo.GetLots()
+	if err != nil {
+		return nil, err
+	}
+
+	purchaseOrders, err := i.purchaseOrderRepo.GetPurchaseOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	stocktakes, err := i.stocktakeRepo.GetStocktakes()
+	if err != nil {
+		return nil, err
+	}
+
+	var menuItems []models.MenuItem
+	var carriers []models.Supplier
+	var drafts []models.PurchaseOrder
+	var counts []models.Stocktake
+	var dependents, blocking []models.Dependent
+	for _, menuItem := range book.menu {
+		if menuItem.DeletedAt == "" && usesIngredient(menuItem, id) {
+			menuItems = append(menuItems, menuItem)
+			dependent := models.Dependent{Type: dependentMenuItem, ID: menuItem.ID, Name: menuItem.Name}
+			dependents = append(dependents, dependent)
+			if len(withoutIngredient(menuItem.Ingredients, id)) == 0 && len(menuItem.Components) == 0 && len(menuItem.Slots) == 0 {
+				blocking = append(blocking, dependent)
+			}
+		}
+	}
+	for _, lot := range lots {
+		if lot.IngredientID == id && lot.Quantity > 0 && !lot.Expired {
+			dependent := models.Dependent{Type: dependentLot, ID: lot.ID}
+			dependents = append(dependents, dependent)
+			blocking = append(blocking, dependent)
+		}
+	}
+	for _, purchaseOrder := range purchaseOrders {
+		line := purchaseOrderLine(purchaseOrder, id)
+		if line < 0 || purchaseOrder.Status == models.PurchaseOrderReceived {
+			continue
+		}
+		dependent := models.Dependent{Type: dependentPurchaseOrder, ID: purchaseOrder.ID}
+		switch {
+		case purchaseOrder.Status == models.PurchaseOrderDraft:
+			drafts = append(drafts, purchaseOrder)
+			dependents = append(dependents, dependent)
+		case purchaseOrder.Lines[line].ReceivedPacks < purchaseOrder.Lines[line].Packs:
+			dependents = append(dependents, dependent)
+			blocking = append(blocking, dependent)
+		}
+	}
+	for _, stocktake := range stocktakes {
+		if stocktake.Status == models.StocktakeOpen && stocktakeLine(stocktake, id) >= 0 {
+			counts = append(counts, stocktake)
+			dependents = append(dependents, models.Dependent{Type: dependentStocktake, ID: stocktake.ID})
+		}
+	}
+	for _, supplier := range suppliers {
+		for _, item := range supplier.Items {
+			if item.IngredientID == id {
+				carriers = append(carriers, supplier)
+				dependents = append(dependents, models.Dependent{Type: dependentSupplier, ID: supplier.ID, Name: supplier.Name})
+				break
+			}
+		}
+	}
+	sortDependents(dependents)
+	sortDependents(blocking)
+
+	if len(dependents) > 0 && !cascade {
+		return refuseDelete(id, dependents)
+	}
+	if len(blocking) > 0 {
+		return refuseDelete(id, blocking)
+	}
+
+	now := time.Now()
+	menu, err := i.menuRepo.GetMenu()
+	if err != nil {
+		return nil, err
+	}
+	var versions []models.RecipeVersion
+	var changedItems []models.MenuItem
+	for _, current := range menuItems {
+		menuItem := current
+		menuItem.Ingredients = withoutIngredient(current.Ingredients, id)
+		versions = append(versions, newRecipeVersions(&current, &menuItem, book, now)...)
+		for j := range menu {
+			if menu[j].ID == menuItem.ID {
+				menu[j] = menuItem
+			}
+		}
+		changedItems = append(changedItems, menuItem)
+	}
+	for j, supplier := range carriers {
+		items := supplier.Items
+		supplier.Items = []models.SupplierItem{}
+		for _, item := range items {
+			if item.IngredientID != id {
+				supplier.Items = append(supplier.Items, item)
+			}
+		}
+		carriers[j] = supplier
+	}
+	for j, purchaseOrder := range drafts {
+		lines := purchaseOrder.Lines
+		purchaseOrder.Lines = []models.PurchaseOrderLine{}
+		purchaseOrder.TotalCost = 0
+		for _, line := range lines {
+			if line.IngredientID != id {
+				purchaseOrder.Lines = append(purchaseOrder.Lines, line)
+				purchaseOrder.TotalCost += line.Packs * line.PackPrice
+			}
+		}
+		drafts[j] = purchaseOrder
+	}
+	for j, stocktake := range counts {
+		stocktake.Lines = slices.DeleteFunc(stocktake.Lines, func(line models.StocktakeLine) bool {
+			return line.IngredientID == id
+		})
+		counts[j] = stocktake
+	}
+
+	if len(changedItems) > 0 {
+		if err := i.menuRepo.SaveMenu(menu); err != nil {
+			return nil, err
+		}
+		if err := saveRecipeVersions(i.recipeRepo, versions); err != nil {
+			return nil, err
+		}
+	}
+	for _, supplier := range carriers {
+		if err := i.supplierRepo.UpdateSupplier(supplier.ID, supplier); err != nil {
+			return nil, err
+		}
+	}
+	for _, purchaseOrder := range drafts {
+		// A draft has nothing left to order without the ingredient.
+		if len(purchaseOrder.Lines) == 0 {
+			err = i.purchaseOrderRepo.DeletePurchaseOrder(purchaseOrder.ID)
+		} else {
+			err = i.purchaseOrderRepo.UpdatePurchaseOrder(purchaseOrder.ID, purchaseOrder)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, stocktake := range counts {
+		if err := i.stocktakeRepo.UpdateStocktake(stocktake.ID, stocktake); err != nil {
+			return nil, err
+		}
+	}
+
+	archived.DeletedAt = now.Format(time.RFC3339)
+	if err := i.repo.UpdateInventory(id, archived); err != nil {
+		return nil, err
+	}
+
+	for _, menuItem := range changedItems {
+		i.events.Publish(models.TopicMenu, models.EventMenuUpdated, menuItem.ID, menuItem)
+	}
+	i.events.Publish(models.TopicInventory, models.EventInventoryDeleted, id, archived)
+	return nil, nil
+}
+
+// Restore an archived ingredient.
+func (i *inventoryService) ServiceRestoreInventory(id string) error {
+	return i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		item, err := i.repo.GetInventoryID(id)
+		if err != nil {
+			return nil, err
+		}
+		if item.DeletedAt == "" {
+			return nil, nil
+		}
+
+		item.DeletedAt = ""
+		if err := i.repo.UpdateInventory(id, item); err != nil {
+			return nil, err
+		}
+		i.events.Publish(models.TopicInventory, models.EventInventoryRestored, id, item)
+		return nil, nil
+	})
+}
+
+type inventoryImportRow struct {
+	row  int
+	item models.InventoryItem
+	err  error
+}
+
+// Import a JSON array or CSV of inventory items, all rows are applied or none of them.
+func (i *inventoryService) ServiceImportInventory(data []byte, isCSV, dryRun, upsert bool) ([]byte, error) {
+	var rows []inventoryImportRow
+	var err error
+	if isCSV {
+		rows, err = decodeInventoryCSV(data)
+	} else {
+		rows, err = decodeInventoryJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, myerrors.ErrNoItems
+	}
+
+	// The rows are checked against the inventory as it is when the ledger is held, so
+	// nothing changes it between the check and the save.
+	result := newImportResult(len(rows), dryRun, upsert)
+	err = i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		inventory, err := i.repo.GetInventory()
+		if err != nil {
+			return nil, err
+		}
+
+		positions := make(map[string]int)
+		for pos, item := range inventory {
+			positions[item.IngredientID] = pos
+		}
+
+		imported := make(map[string]bool)
+		var movements []models.InventoryMovement
+
+		for _, row := range rows {
+			row.item.DeletedAt = ""
+			if row.err == nil {
+				row.err = validation.CheckInventory(row.item)
+			}
+			if row.err == nil && imported[row.item.IngredientID] {
+				row.err = myerrors.ErrDuplicateID
+			}
+			pos, exists := positions[row.item.IngredientID]
+			if row.err == nil && exists && !upsert {
+				row.err = myerrors.ErrIDExist
+			}
+			if row.err == nil && exists && inventory[pos].DeletedAt != "" {
+				row.err = myerrors.ErrArchived
+			}
+			if row.err == nil && exists && units.Normalize(inventory[pos].Unit) != units.Normalize(row.item.Unit) {
+				row.err = i.checkRecipeUnits(row.item)
+			}
+			if row.err == nil {
+				if !exists {
+					inventory = append(inventory, row.item)
+					positions[row.item.IngredientID] = len(inventory) - 1
+					movements = append(movements, models.InventoryMovement{
+						IngredientID: row.item.IngredientID,
+						Delta:        row.item.Quantity,
+						Reason:       models.MovementReceipt,
+					})
+					result.Created++
+				} else {
+					movements = append(movements, models.InventoryMovement{
+						IngredientID: row.item.IngredientID,
+						Delta:        row.item.Quantity - inventory[pos].Quantity,
+						Reason:       models.MovementAdjustment,
+					})
+					inventory[pos] = row.item
+					result.Updated++
+				}
+			}
+
+			if row.err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Row: row.row, ID: row.item.IngredientID, Error: row.err.Error()})
+				continue
+			}
+			imported[row.item.IngredientID] = true
+		}
+
+		if len(result.Errors) > 0 || dryRun {
+			return nil, nil
+		}
+		if err := i.repo.SaveInventory(inventory); err != nil {
+			return nil, err
+		}
+		i.events.Publish(models.TopicInventory, models.EventInventoryImported, "", result)
+		return movements, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalImportResult(result)
+}
+
+func decodeInventoryJSON(data []byte) ([]inventoryImportRow, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	rows := make([]inventoryImportRow, 0, len(raws))
+	for i, raw := range raws {
+		row := inventoryImportRow{row: i + 1}
+		if err := json.Unmarshal(raw, &row.item); err != nil {
+			row.err = myerrors.ErrInvalidJson
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeInventoryCSV(data []byte) ([]inventoryImportRow, error) {
+	lines, err := csvencoder.Decode(data)
+	if err != nil {
+		slog.Error("Failed to read csv", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	rows := make([]inventoryImportRow, 0, len(lines))
+	for i, line := range lines {
+		row := inventoryImportRow{
+			row: i + 2,
+			item: models.InventoryItem{
+				IngredientID: line["ingredient_id"],
+				Name:         line["name"],
+				Unit:         line["unit"],
+			},
+		}
+		quantity, err := strconv.ParseFloat(line["quantity"], 64)
+		if err != nil {
+			row.err = myerrors.ErrInvalidQuantity
+		}
+		row.item.Quantity = quantity
+		if line["allergens"] != "" {
+			row.item.Allergens = strings.Split(line["allergens"], ";")
+		}
+		if nutrition, ok, err := decodeNutritionCSV(line); err != nil && row.err == nil {
+			row.err = err
+		} else if ok {
+			row.item.Nutrition = &nutrition
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeNutritionCSV reads the nutrition.* columns, ok is false when they are all empty.
+func decodeNutritionCSV(line map[string]string) (nutrition models.Nutrition, ok bool, err error) {
+	fields := map[string]*float64{
+		"nutrition.calories":      &nutrition.Calories,
+		"nutrition.fat":           &nutrition.Fat,
+		"nutrition.carbohydrates": &nutrition.Carbohydrates,
+		"nutrition.sugar":         &nutrition.Sugar,
+		"nutrition.protein":       &nutrition.Protein,
+		"nutrition.salt":          &nutrition.Salt,
+	}
+	for column, value := range fields {
+		if line[column] == "" {
+			continue
+		}
+		ok = true
+		if *value, err = strconv.ParseFloat(line[column], 64); err != nil {
+			return nutrition, false, myerrors.ErrInvalidNutrition
+		}
+	}
+	return nutrition, ok, nil
+}
+
+// Change the stock of an ingredient by a relative amount with a reason code.
+func (i *inventoryService) ServiceAdjustInventory(id string, adjustmentByte []byte) error {
+	var adjustment models.StockAdjustment
+	if err := json.Unmarshal(adjustmentByte, &adjustment); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return myerrors.ErrFailUnmarshal
+	}
+
+	if err := validation.CheckAdjustment(adjustment); err != nil {
+		return err
+	}
+
+	// The stock is read and changed in one ledger step, so adjustments made at the same
+	// time can not together take it below zero.
+	return i.ledger.Record(func() ([]models.InventoryMovement, error) {
+		item, err := i.repo.GetInventoryID(id)
+		if err != nil {
+			return nil, err
+		}
+		if item.DeletedAt != "" {
+			slog.Error("Failed to adjust inventory", "error", myerrors.ErrArchived, "ingredient", id)
+			return nil, myerrors.ErrArchived
+		}
+
+		movements := []models.InventoryMovement{{
+			IngredientID: id,
+			Delta:        adjustment.Delta,
+			Reason:       adjustment.Reason,
+			ReferenceID:  adjustment.ReferenceID,
+			Note:         adjustment.Note,
+		}}
+		if err := changeInventory(i.repo, movements); err != nil {
+			return nil, err
+		}
+		return movements, nil
+	})
 }
 
-func (i *inventoryService) ServiceDeleteInventory(id string) error {
-	return i.repo.DeleteInventory(id)
+// Retrieve the movement history of an ingredient, oldest first, optionally for one reason.
+func (i *inventoryService) ServiceGetMovements(id string, reason string) ([]byte, error) {
+	if _, err := i.repo.GetInventoryID(id); err != nil {
+		return nil, err
+	}
+
+	movements, err := i.movementRepo.GetMovements()
+	if err != nil {
+		return nil, err
+	}
+
+	history := []models.InventoryMovement{}
+	for _, movement := range movements {
+		if movement.IngredientID != id || (reason != "" && movement.Reason != reason) {
+			continue
+		}
+		history = append(history, movement)
+	}
+
+	jsonFile, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
 }
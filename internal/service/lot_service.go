@@ -0,0 +1,155 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LotService interface {
+	ServiceGetLots(ingredientID string) ([]byte, error)
+	ServiceCreateLot(ingredientID string, newLot []byte) error
+	ServiceGetExpiringLots(days int) ([]byte, error)
+	ServiceExpireLots() error
+}
+
+type lotService struct {
+	lotRepo       dal.LotRepository
+	inventoryRepo dal.InventoryRepository
+	ledger        StockLedger
+}
+
+func NewLotService(lotRepo dal.LotRepository, inventoryRepo dal.InventoryRepository, ledger StockLedger) LotService {
+	return &lotService{
+		lotRepo:       lotRepo,
+		inventoryRepo: inventoryRepo,
+		ledger:        ledger,
+	}
+}
+
+// Retrieve the lots of an ingredient that still hold stock, first to expire first.
+func (l *lotService) ServiceGetLots(ingredientID string) ([]byte, error) {
+	if _, err := l.inventoryRepo.GetInventoryID(ingredientID); err != nil {
+		return nil, err
+	}
+
+	lots, err := l.lotRepo.GetLots()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.InventoryLot{}
+	for _, lot := range lots {
+		if lot.IngredientID == ingredientID && lot.Quantity > 0 {
+			result = append(result, lot)
+		}
+	}
+	sortLotsByExpiry(result)
+
+	return marshalLots(result)
+}
+
+// Receive a lot of an ingredient, its quantity is added to the inventory.
+func (l *lotService) ServiceCreateLot(ingredientID string, newLot []byte) error {
+	var lot models.InventoryLot
+	if err := json.Unmarshal(newLot, &lot); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return myerrors.ErrFailUnmarshal
+	}
+
+	if _, err := l.inventoryRepo.GetInventoryID(ingredientID); err != nil {
+		return err
+	}
+
+	lot, err := newInventoryLot(ingredientID, lot.Quantity, lot.ExpiresAt, lot.ReferenceID)
+	if err != nil {
+		return err
+	}
+
+	return l.ledger.Receive([]models.InventoryLot{lot}, []models.InventoryMovement{{
+		IngredientID: ingredientID,
+		Delta:        lot.Quantity,
+		Reason:       models.MovementReceipt,
+		ReferenceID:  lot.ReferenceID,
+		LotID:        lot.ID,
+	}})
+}
+
+// Retrieve lots holding stock that expire within the given number of days, including
+// lots already past their date that have not been written off yet.
+func (l *lotService) ServiceGetExpiringLots(days int) ([]byte, error) {
+	lots, err := l.lotRepo.GetLots()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := time.Now().AddDate(0, 0, days)
+	result := []models.InventoryLot{}
+	for _, lot := range lots {
+		if lot.Quantity > 0 && !lotExpiry(lot).After(limit) {
+			result = append(result, lot)
+		}
+	}
+	sortLotsByExpiry(result)
+
+	return marshalLots(result)
+}
+
+// ServiceExpireLots writes off the remaining stock of every expired lot as waste.
+func (l *lotService) ServiceExpireLots() error {
+	expired, err := l.ledger.ExpireLots(time.Now())
+	for _, lot := range expired {
+		slog.Warn("Lot expired", "event", "lot.expired", "lot", lot.ID, "ingredient", lot.IngredientID)
+	}
+	return err
+}
+
+// newInventoryLot validates a received quantity and expiry date. A date without a time
+// expires at the end of that day.
+func newInventoryLot(ingredientID string, quantity float64, expiresAt string, referenceID string) (models.InventoryLot, error) {
+	if quantity <= 0 {
+		slog.Error("Validation failed: Quantity must be >0", "quantity", quantity)
+		return models.InventoryLot{}, myerrors.ErrInvalidQuantity
+	}
+
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		day, err := time.ParseInLocation(time.DateOnly, expiresAt, time.Local)
+		if err != nil {
+			slog.Error("Validation failed: Expiry date is invalid", "expires at", expiresAt)
+			return models.InventoryLot{}, myerrors.ErrInvalidExpiry
+		}
+		expiry = day.AddDate(0, 0, 1)
+	}
+
+	return models.InventoryLot{
+		ID:              uuid.NewID("lot"),
+		IngredientID:    ingredientID,
+		Quantity:        quantity,
+		InitialQuantity: quantity,
+		ReceivedAt:      time.Now().Format(time.RFC3339),
+		ExpiresAt:       expiry.Format(time.RFC3339),
+		ReferenceID:     referenceID,
+	}, nil
+}
+
+func sortLotsByExpiry(lots []models.InventoryLot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lotExpiry(lots[i]).Before(lotExpiry(lots[j]))
+	})
+}
+
+func marshalLots(lots []models.InventoryLot) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(lots, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// loyaltyProgram is the set of loyalty rules with the log of what customers earned
+// and spent under them.
+type loyaltyProgram struct {
+	rules        map[string]models.LoyaltyRule
+	transactions []models.LoyaltyTransaction
+}
+
+func loadLoyaltyProgram(ruleRepo dal.LoyaltyRuleRepository, transactionRepo dal.LoyaltyTransactionRepository) (loyaltyProgram, error) {
+	rules, err := ruleRepo.GetLoyaltyRules()
+	if err != nil {
+		return loyaltyProgram{}, err
+	}
+	transactions, err := transactionRepo.GetLoyaltyTransactions()
+	if err != nil {
+		return loyaltyProgram{}, err
+	}
+
+	byID := make(map[string]models.LoyaltyRule)
+	for _, rule := range rules {
+		byID[rule.ID] = rule
+	}
+	return loyaltyProgram{rules: byID, transactions: transactions}, nil
+}
+
+// balance adds up what a customer earned and spent under a rule.
+func (p loyaltyProgram) balance(customerID, ruleID string) int {
+	balance := 0
+	for _, transaction := range p.transactions {
+		if transaction.CustomerID == customerID && transaction.RuleID == ruleID {
+			balance += transaction.Amount
+		}
+	}
+	return balance
+}
+
+// redeem spends the redemptions of a new order: the rules must be active and the
+// customer must have enough points or stamps. It sets the discount and total of the
+// order and returns the transactions to record once the order is saved.
+func (p loyaltyProgram) redeem(order *models.Order, book recipeBook) ([]models.LoyaltyTransaction, error) {
+	spent := make(map[string]int)
+	var transactions []models.LoyaltyTransaction
+	for _, redemption := range order.Redemptions {
+		rule, ok := p.rules[redemption.RuleID]
+		if !ok || !rule.Active {
+			slog.Error("Failed to redeem rewards", "error", myerrors.ErrUnknownRule, "rule", redemption.RuleID)
+			return nil, myerrors.ErrUnknownRule
+		}
+
+		cost := rule.RewardCost * redemption.Rewards
+		spent[rule.ID] += cost
+		if spent[rule.ID] > p.balance(order.CustomerID, rule.ID) {
+			slog.Error("Failed to redeem rewards", "error", myerrors.ErrInsufficientBalance, "customer", order.CustomerID, "rule", rule.ID)
+			return nil, myerrors.ErrInsufficientBalance
+		}
+
+		transactions = append(transactions, models.LoyaltyTransaction{
+			CustomerID: order.CustomerID,
+			RuleID:     rule.ID,
+			Type:       models.LoyaltyRedeem,
+			Amount:     -cost,
+		})
+	}
+
+	if err := p.discountOrder(order, book); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// discountOrder takes the rewards of the order's redemptions off its total, the
+// discount is never more than the total.
+func (p loyaltyProgram) discountOrder(order *models.Order, book recipeBook) error {
+	discount := 0.0
+	for _, redemption := range order.Redemptions {
+		rule, ok := p.rules[redemption.RuleID]
+		if !ok {
+			slog.Error("Failed to discount order", "error", myerrors.ErrUnknownRule, "rule", redemption.RuleID)
+			return myerrors.ErrUnknownRule
+		}
+
+		if rule.RewardValue > 0 {
+			discount += rule.RewardValue * float64(redemption.Rewards)
+			continue
+		}
+
+		// A free item reward makes the most expensive items of the category free.
+		var prices []float64
+		for _, item := range order.Items {
+			if book.menu[item.ProductID].CategoryID != rule.CategoryID {
+				continue
+			}
+			for i := 0; i < item.Quantity; i++ {
+				prices = append(prices, orderPrice(item, book.menu[item.ProductID]))
+			}
+		}
+		if len(prices) < redemption.Rewards {
+			slog.Error("Failed to discount order", "error", myerrors.ErrNoRewardItem, "rule", rule.ID)
+			return myerrors.ErrNoRewardItem
+		}
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+		for _, price := range prices[:redemption.Rewards] {
+			discount += price
+		}
+	}
+
+	order.Discount = round2(math.Min(discount, order.Total))
+	order.Total = round2(order.Total - order.Discount)
+	return nil
+}
+
+// earn returns what the customer of a closed order earns under the active rules.
+func (p loyaltyProgram) earn(order models.Order, book recipeBook) []models.LoyaltyTransaction {
+	ruleIDs := make([]string, 0, len(p.rules))
+	for id := range p.rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+
+	var transactions []models.LoyaltyTransaction
+	for _, id := range ruleIDs {
+		rule := p.rules[id]
+		if !rule.Active {
+			continue
+		}
+
+		amount := 0
+		switch rule.Type {
+		case models.LoyaltyPoints:
+			amount = int(math.Floor(order.Total*rule.PointsPerUnit + 1e-9))
+		case models.LoyaltyStamps:
+			for _, item := range order.Items {
+				if book.menu[item.ProductID].CategoryID == rule.CategoryID {
+					amount += item.Quantity
+				}
+			}
+		}
+		if amount > 0 {
+			transactions = append(transactions, models.LoyaltyTransaction{
+				CustomerID: order.CustomerID,
+				RuleID:     rule.ID,
+				OrderID:    order.ID,
+				Type:       models.LoyaltyEarn,
+				Amount:     amount,
+			})
+		}
+	}
+	return transactions
+}
+
+// reverse returns the reversals that bring what an order earned and spent, net of
+// earlier reversals, back to zero.
+func (p loyaltyProgram) reverse(orderID string) []models.LoyaltyTransaction {
+	type account struct{ customerID, ruleID string }
+	var accounts []account
+	net := make(map[account]int)
+	for _, transaction := range p.transactions {
+		if transaction.OrderID != orderID {
+			continue
+		}
+		key := account{transaction.CustomerID, transaction.RuleID}
+		if _, ok := net[key]; !ok {
+			accounts = append(accounts, key)
+		}
+		net[key] += transaction.Amount
+	}
+
+	var transactions []models.LoyaltyTransaction
+	for _, key := range accounts {
+		if net[key] == 0 {
+			continue
+		}
+		transactions = append(transactions, models.LoyaltyTransaction{
+			CustomerID: key.customerID,
+			RuleID:     key.ruleID,
+			OrderID:    orderID,
+			Type:       models.LoyaltyReversal,
+			Amount:     -net[key],
+		})
+	}
+	return transactions
+}
+
+// respend spends the redemptions of a restored order again, at the current cost of
+// their rules. The customer must still have enough points or stamps.
+func (p loyaltyProgram) respend(order models.Order) ([]models.LoyaltyTransaction, error) {
+	spent := make(map[string]int)
+	var transactions []models.LoyaltyTransaction
+	for _, redemption := range order.Redemptions {
+		rule, ok := p.rules[redemption.RuleID]
+		if !ok {
+			slog.Error("Failed to redeem rewards", "error", myerrors.ErrUnknownRule, "rule", redemption.RuleID)
+			return nil, myerrors.ErrUnknownRule
+		}
+
+		cost := rule.RewardCost * redemption.Rewards
+		spent[rule.ID] += cost
+		if spent[rule.ID] > p.balance(order.CustomerID, rule.ID) {
+			slog.Error("Failed to redeem rewards", "error", myerrors.ErrInsufficientBalance, "customer", order.CustomerID, "rule", rule.ID)
+			return nil, myerrors.ErrInsufficientBalance
+		}
+
+		transactions = append(transactions, models.LoyaltyTransaction{
+			CustomerID: order.CustomerID,
+			RuleID:     rule.ID,
+			OrderID:    order.ID,
+			Type:       models.LoyaltyRedeem,
+			Amount:     -cost,
+		})
+	}
+	return transactions, nil
+}
+
+// releaseRewards gives back the rewards an open order spent, when it is archived.
+func releaseRewards(ruleRepo dal.LoyaltyRuleRepository, transactionRepo dal.LoyaltyTransactionRepository, order models.Order, now time.Time) error {
+	if order.Status != "open" || len(order.Redemptions) == 0 {
+		return nil
+	}
+	program, err := loadLoyaltyProgram(ruleRepo, transactionRepo)
+	if err != nil {
+		return err
+	}
+	return saveLoyaltyTransactions(transactionRepo, program.reverse(order.ID), now)
+}
+
+func saveLoyaltyTransactions(transactionRepo dal.LoyaltyTransactionRepository, transactions []models.LoyaltyTransaction, now time.Time) error {
+	for _, transaction := range transactions {
+		transaction.ID = uuid.NewID("loyalty")
+		transaction.CreatedAt = now.Format(time.RFC3339)
+		if err := transactionRepo.CreateLoyaltyTransaction(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
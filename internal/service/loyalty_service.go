@@ -0,0 +1,203 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+	"strings"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type LoyaltyService interface {
+	ServiceGetRules() ([]byte, error)
+	ServiceGetRuleID(id string) ([]byte, error)
+	ServiceCreateRule(newRule []byte) ([]byte, error)
+	ServiceUpdateRule(id string, newRule []byte) error
+	ServiceDeleteRule(id string) error
+	ServiceGetAccount(customerID string) ([]byte, error)
+}
+
+type loyaltyService struct {
+	ruleRepo     dal.LoyaltyRuleRepository
+	loyaltyRepo  dal.LoyaltyTransactionRepository
+	customerRepo dal.CustomerRepository
+	categoryRepo dal.CategoryRepository
+}
+
+func NewLoyaltyService(ruleRepo dal.LoyaltyRuleRepository, loyaltyRepo dal.LoyaltyTransactionRepository, customerRepo dal.CustomerRepository, categoryRepo dal.CategoryRepository) LoyaltyService {
+	return &loyaltyService{
+		ruleRepo:     ruleRepo,
+		loyaltyRepo:  loyaltyRepo,
+		customerRepo: customerRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// Retrieve all loyalty rules by name.
+func (l *loyaltyService) ServiceGetRules() ([]byte, error) {
+	rules, err := l.ruleRepo.GetLoyaltyRules()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return strings.ToLower(rules[i].Name) < strings.ToLower(rules[j].Name)
+	})
+
+	jsonFile, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (l *loyaltyService) ServiceGetRuleID(id string) ([]byte, error) {
+	rule, err := l.ruleRepo.GetLoyaltyRuleID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(rule, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Create a loyalty rule, the ID is generated.
+func (l *loyaltyService) ServiceCreateRule(newRule []byte) ([]byte, error) {
+	rule, err := l.decodeRule(newRule)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = uuid.NewID("rule")
+	if err := l.ruleRepo.CreateLoyaltyRule(rule); err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(rule, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Update a loyalty rule, the new terms apply to the orders closed from now on.
+func (l *loyaltyService) ServiceUpdateRule(id string, newRule []byte) error {
+	rule, err := l.decodeRule(newRule)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.ruleRepo.GetLoyaltyRuleID(id); err != nil {
+		return err
+	}
+
+	rule.ID = id
+	return l.ruleRepo.UpdateLoyaltyRule(id, rule)
+}
+
+// Delete a loyalty rule nobody earned or spent anything under yet.
+func (l *loyaltyService) ServiceDeleteRule(id string) error {
+	if _, err := l.ruleRepo.GetLoyaltyRuleID(id); err != nil {
+		return err
+	}
+
+	transactions, err := l.loyaltyRepo.GetLoyaltyTransactions()
+	if err != nil {
+		return err
+	}
+	for _, transaction := range transactions {
+		if transaction.RuleID == id {
+			slog.Error("Failed to delete loyalty rule", "error", myerrors.ErrRuleInUse, "id", id)
+			return myerrors.ErrRuleInUse
+		}
+	}
+
+	return l.ruleRepo.DeleteLoyaltyRule(id)
+}
+
+// Retrieve the balance of a customer under every rule and the history of the
+// customer's loyalty transactions, newest first.
+func (l *loyaltyService) ServiceGetAccount(customerID string) ([]byte, error) {
+	customer, err := l.customerRepo.GetCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := loadLoyaltyProgram(l.ruleRepo, l.loyaltyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	account := models.LoyaltyAccount{
+		CustomerID: customer.ID,
+		Name:       customer.Name,
+		Balances:   []models.LoyaltyBalance{},
+		History:    []models.LoyaltyTransaction{},
+	}
+	for _, rule := range program.rules {
+		balance := program.balance(customer.ID, rule.ID)
+		account.Balances = append(account.Balances, models.LoyaltyBalance{
+			RuleID:  rule.ID,
+			Name:    rule.Name,
+			Type:    rule.Type,
+			Balance: balance,
+			Rewards: max(balance, 0) / rule.RewardCost,
+		})
+	}
+	sort.SliceStable(account.Balances, func(i, j int) bool {
+		return strings.ToLower(account.Balances[i].Name) < strings.ToLower(account.Balances[j].Name)
+	})
+
+	for _, transaction := range program.transactions {
+		if transaction.CustomerID == customer.ID {
+			account.History = append(account.History, transaction)
+		}
+	}
+	sort.SliceStable(account.History, func(i, j int) bool {
+		return account.History[i].CreatedAt > account.History[j].CreatedAt
+	})
+
+	jsonFile, err := json.MarshalIndent(account, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// decodeRule unmarshals and validates a loyalty rule, a rule is active unless it says
+// otherwise and a stamps rule must name an existing category.
+func (l *loyaltyService) decodeRule(data []byte) (models.LoyaltyRule, error) {
+	rule := models.LoyaltyRule{Active: true}
+	if err := json.Unmarshal(data, &rule); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.LoyaltyRule{}, myerrors.ErrFailUnmarshal
+	}
+	rule.Name = strings.TrimSpace(rule.Name)
+
+	if err := validation.CheckLoyaltyRule(rule); err != nil {
+		return models.LoyaltyRule{}, err
+	}
+
+	if rule.Type == models.LoyaltyStamps {
+		if _, err := l.categoryRepo.GetCategoryID(rule.CategoryID); err == myerrors.ErrNotFound {
+			slog.Error("Failed to save loyalty rule", "error", myerrors.ErrUnknownCategory, "category", rule.CategoryID)
+			return models.LoyaltyRule{}, myerrors.ErrUnknownCategory
+		} else if err != nil {
+			return models.LoyaltyRule{}, err
+		}
+	} else {
+		rule.CategoryID = ""
+	}
+	return rule, nil
+}
@@ -3,27 +3,64 @@ package service
 import (
 	"encoding/json"
 	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/csvencoder"
 	"hot-coffee/internal/utils/validation"
 	"hot-coffee/models"
 	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
 
 type MenuService interface {
-	ServiceGetMenu() ([]byte, error)
+	ServiceGetMenu(filter MenuFilter) ([]byte, error)
 	ServiceGetMenuID(id string) ([]byte, error)
 	ServiceCreateMenu(newMenuItem []byte) error
 	ServiceUpdateMenu(id string, newMenu []byte) error
-	ServiceDeleteMenu(id string) error
+	ServiceDeleteMenu(id string, cascade bool) ([]byte, error)
+	ServiceRestoreMenu(id string) error
+	ServiceImportMenu(data []byte, isCSV, dryRun, upsert bool) ([]byte, error)
+	ServiceGetRecipeVersions(id string) ([]byte, error)
+}
+
+// MenuFilter selects what GET /menu returns.
+type MenuFilter struct {
+	// OnlyAvailable leaves out what cannot be made right now.
+	OnlyAvailable bool
+	// GroupByCategory returns the items grouped by category instead of one list.
+	GroupByCategory bool
+	// At lists only the items that can be ordered at that time, the zero time lists
+	// everything and tells whether it can be ordered now.
+	At time.Time
+	// ExcludeAllergens leaves out the items that contain any of these allergens.
+	ExcludeAllergens []string
+	// IncludeArchived lists archived items too.
+	IncludeArchived bool
 }
 
 type menuService struct {
-	menuRepo dal.MenuRepository
+	menuRepo      dal.MenuRepository
+	recipeRepo    dal.RecipeVersionRepository
+	orderRepo     dal.OrderRepository
+	inventoryRepo dal.InventoryRepository
+	unitRepo      dal.UnitRepository
+	categoryRepo  dal.CategoryRepository
+	priceRepo     dal.PriceRepository
+	ruleRepo      dal.LoyaltyRuleRepository
+	loyaltyRepo   dal.LoyaltyTransactionRepository
+	cardRepo      dal.GiftCardRepository
+	cardLogRepo   dal.GiftCardTransactionRepository
+	orders        OrderService
+	events        EventBus
+	location      *time.Location
 }
 
-func NewMenuService(repo dal.MenuRepository) MenuService {
-	return &menuService{menuRepo: repo}
+func NewMenuService(repo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, orderRepo dal.OrderRepository, inventoryRepo dal.InventoryRepository, unitRepo dal.UnitRepository, categoryRepo dal.CategoryRepository, priceRepo dal.PriceRepository, ruleRepo dal.LoyaltyRuleRepository, loyaltyRepo dal.LoyaltyTransactionRepository, cardRepo dal.GiftCardRepository, cardLogRepo dal.GiftCardTransactionRepository, orders OrderService, events EventBus, location *time.Location) MenuService {
+	return &menuService{menuRepo: repo, recipeRepo: recipeRepo, orderRepo: orderRepo, inventoryRepo: inventoryRepo, unitRepo: unitRepo, categoryRepo: categoryRepo, priceRepo: priceRepo, ruleRepo: ruleRepo, loyaltyRepo: loyaltyRepo, cardRepo: cardRepo, cardLogRepo: cardLogRepo, orders: orders, events: events, location: location}
 }
 
 func (m *menuService) ServiceCreateMenu(newMenuItem []byte) error {
@@ -32,31 +69,343 @@ func (m *menuService) ServiceCreateMenu(newMenuItem []byte) error {
 		slog.Error("Failed to unmarshal", "error", err)
 		return myerrors.ErrFailUnmarshal
 	}
+	menu.DeletedAt = ""
 
 	if err := validation.CheckMenu(menu); err != nil {
 		return err
 	}
 
+	if err := m.checkRecipe(menu); err != nil {
+		return err
+	}
+
 	checkMenuID, _ := m.menuRepo.GetMenuID(menu.ID)
 	if checkMenuID.ID == menu.ID {
 		slog.Error("Failed to create menu", "error", myerrors.ErrIDExist)
 		return myerrors.ErrIDExist
 	}
 
-	return m.menuRepo.CreateMenu(menu)
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	versions := newRecipeVersions(nil, &menu, book, now)
+	if err := m.menuRepo.CreateMenu(menu); err != nil {
+		return err
+	}
+	if err := saveRecipeVersions(m.recipeRepo, versions); err != nil {
+		return err
+	}
+
+	if _, err := recordPrice(m.priceRepo, menu.ID, menu.Price, now); err != nil {
+		return err
+	}
+	m.events.Publish(models.TopicMenu, models.EventMenuCreated, menu.ID, menu)
+	return nil
+}
+
+// Archive a menu item that no open order or bundle uses any more. With cascade its
+// lines are first taken out of the open orders, orders left without lines are
+// archived and their gift card payments refunded, and it is taken out of the bundles,
+// recording new recipe versions; otherwise they are returned as dependents. The
+// cascade is refused when an order would be left below what was paid on it.
+func (m *menuService) ServiceDeleteMenu(id string, cascade bool) ([]byte, error) {
+	// The orders lock is held throughout, so no order starts using the item or is
+	// changed between the check and the cascade.
+	var dependents []byte
+	err := m.orders.UpdateOrders(func() error {
+		var err error
+		dependents, err = m.deleteMenu(id, cascade)
+		return err
+	})
+	return dependents, err
+}
+
+// deleteMenu works out and checks every change of the cascade before it writes any.
+// The orders are written first and the menu last in one write, so a failed write
+// leaves the item in fewer orders, never archived while orders still hold it.
+func (m *menuService) deleteMenu(id string, cascade bool) ([]byte, error) {
+	archived, err := m.menuRepo.GetMenuID(id)
+	if err != nil {
+		return nil, err
+	}
+	if archived.DeletedAt != "" {
+		slog.Error("Failed to delete menu", "error", myerrors.ErrArchived, "product", id)
+		return nil, myerrors.ErrArchived
+	}
+
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := m.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	var openOrders []models.Order
+	var bundles []models.MenuItem
+	var dependents []models.Dependent
+	for _, order := range orders {
+		if order.Status == "open" && order.DeletedAt == "" && orderUsesProduct(order, id) {
+			openOrders = append(openOrders, order)
+			dependents = append(dependents, models.Dependent{Type: dependentOrder, ID: order.ID, Name: order.CustomerName})
+		}
+	}
+	for _, menuItem := range book.menu {
+		if menuItem.ID != id && menuItem.DeletedAt == "" && usesComponent(menuItem, id) {
+			bundles = append(bundles, menuItem)
+			dependents = append(dependents, models.Dependent{Type: dependentMenuItem, ID: menuItem.ID, Name: menuItem.Name})
+		}
+	}
+	sortDependents(dependents)
+
+	if len(dependents) > 0 && !cascade {
+		return refuseDelete(id, dependents)
+	}
+
+	now := time.Now()
+	for i, order := range openOrders {
+		items := order.Items
+		order.Items = nil
+		order.Total = 0
+		for _, item := range items {
+			if lineUsesProduct(item, id) {
+				continue
+			}
+			order.Items = append(order.Items, item)
+			order.Total += orderPrice(item, book.menu[item.ProductID]) * float64(item.Quantity)
+		}
+		order.Discount = round2(math.Min(order.Discount, order.Total))
+		order.Total = round2(order.Total - order.Discount)
+
+		if len(order.Items) > 0 && order.Total < giftCardPaid(order) {
+			slog.Error("Failed to delete menu", "error", myerrors.ErrBelowPaid, "product", id, "order", order.ID)
+			return nil, myerrors.ErrBelowPaid
+		}
+		openOrders[i] = order
+	}
+
+	// Orders left without lines are archived, the rewards they spent are given back
+	// and their gift card payments refunded, the cards must still be there.
+	program, err := loadLoyaltyProgram(m.ruleRepo, m.loyaltyRepo)
+	if err != nil {
+		return nil, err
+	}
+	var released []models.LoyaltyTransaction
+	var refunded []models.Order
+	for i, order := range openOrders {
+		if len(order.Items) > 0 {
+			continue
+		}
+		for _, payment := range order.GiftCards {
+			if _, err := m.cardRepo.GetGiftCardID(payment.Code); err != nil {
+				return nil, err
+			}
+		}
+		if len(order.Redemptions) > 0 {
+			released = append(released, program.reverse(order.ID)...)
+		}
+		refunded = append(refunded, order)
+		order.DeletedAt = now.Format(time.RFC3339)
+		order.GiftCards = nil
+		openOrders[i] = order
+	}
+
+	menu, err := m.menuRepo.GetMenu()
+	if err != nil {
+		return nil, err
+	}
+	var versions []models.RecipeVersion
+	var changedBundles []models.MenuItem
+	for _, current := range bundles {
+		bundle := withoutComponent(current, id)
+		versions = append(versions, newRecipeVersions(&current, &bundle, book, now)...)
+		changedBundles = append(changedBundles, bundle)
+	}
+	archived.DeletedAt = now.Format(time.RFC3339)
+	for i, menuItem := range menu {
+		if menuItem.ID == id {
+			menu[i] = archived
+		}
+		for _, bundle := range changedBundles {
+			if menuItem.ID == bundle.ID {
+				menu[i] = bundle
+			}
+		}
+	}
+
+	for _, order := range openOrders {
+		if err := m.orderRepo.UpdateOrder(order.ID, order); err != nil {
+			return nil, err
+		}
+	}
+	if err := saveLoyaltyTransactions(m.loyaltyRepo, released, now); err != nil {
+		return nil, err
+	}
+	for _, order := range refunded {
+		if err := refundGiftCards(m.cardRepo, m.cardLogRepo, order, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.menuRepo.SaveMenu(menu); err != nil {
+		return nil, err
+	}
+	if err := saveRecipeVersions(m.recipeRepo, versions); err != nil {
+		return nil, err
+	}
+
+	for _, order := range openOrders {
+		eventType := models.EventOrderUpdated
+		if order.DeletedAt != "" {
+			eventType = models.EventOrderDeleted
+		}
+		m.events.Publish(models.TopicOrders, eventType, order.ID, order)
+	}
+	for _, bundle := range changedBundles {
+		m.events.Publish(models.TopicMenu, models.EventMenuUpdated, bundle.ID, bundle)
+	}
+	m.events.Publish(models.TopicMenu, models.EventMenuDeleted, id, archived)
+	return nil, nil
 }
 
-func (m *menuService) ServiceDeleteMenu(id string) error {
-	return m.menuRepo.DeleteMenu(id)
+// Restore an archived menu item, its ingredients and components must not be archived.
+func (m *menuService) ServiceRestoreMenu(id string) error {
+	menuItem, err := m.menuRepo.GetMenuID(id)
+	if err != nil {
+		return err
+	}
+	if menuItem.DeletedAt == "" {
+		return nil
+	}
+
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return err
+	}
+	if err := book.checkIngredients(menuItem); err != nil {
+		return err
+	}
+	if err := book.checkComponents(menuItem); err != nil {
+		return err
+	}
+
+	menuItem.DeletedAt = ""
+	if err := m.menuRepo.UpdateMenu(id, menuItem); err != nil {
+		return err
+	}
+	m.events.Publish(models.TopicMenu, models.EventMenuRestored, id, menuItem)
+	return nil
+}
+
+// withoutComponent takes productID out of the components and slot options of a
+// bundle, slots left without options are dropped.
+func withoutComponent(bundle models.MenuItem, productID string) models.MenuItem {
+	components, slots := bundle.Components, bundle.Slots
+	bundle.Components, bundle.Slots = nil, nil
+	for _, component := range components {
+		if component.ProductID != productID {
+			bundle.Components = append(bundle.Components, component)
+		}
+	}
+	for _, slot := range slots {
+		options := slot.Options
+		slot.Options = nil
+		for _, option := range options {
+			if option != productID {
+				slot.Options = append(slot.Options, option)
+			}
+		}
+		if len(slot.Options) > 0 {
+			bundle.Slots = append(bundle.Slots, slot)
+		}
+	}
+	return bundle
 }
 
-func (m *menuService) ServiceGetMenu() ([]byte, error) {
+// Retrieve the menu with availability in display order: by category, then by the
+// sort order of the items. Items of inactive categories are left out, schedules are
+// evaluated in the shop's time zone.
+func (m *menuService) ServiceGetMenu(filter MenuFilter) ([]byte, error) {
 	menu, err := m.menuRepo.GetMenu()
 	if err != nil {
 		return nil, err
 	}
 
-	jsonFile, err := json.MarshalIndent(menu, "", "  ")
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := m.categoryRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	sortCategories(categories)
+
+	positions := make(map[string]int)
+	categoryIDs := make(map[string]models.Category)
+	for i, category := range categories {
+		positions[category.ID] = i
+		categoryIDs[category.ID] = category
+	}
+	position := func(menuItem models.MenuItem) int {
+		if i, ok := positions[menuItem.CategoryID]; ok {
+			return i
+		}
+		return len(categories)
+	}
+
+	prices, err := loadPriceList(m.priceRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	at := filter.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	views := []models.MenuItemView{}
+	for _, menuItem := range menu {
+		if menuItem.DeletedAt != "" && !filter.IncludeArchived {
+			continue
+		}
+		if i, ok := positions[menuItem.CategoryID]; ok && !categories[i].Active {
+			continue
+		}
+		view := menuItemView(menuItem, book)
+		view.Price = prices.priceAt(menuItem, at)
+		view.Orderable = menuItemOrderable(menuItem, book, categoryIDs, at.In(m.location))
+		if filter.OnlyAvailable && !view.Available {
+			continue
+		}
+		if !filter.At.IsZero() && !view.Orderable {
+			continue
+		}
+		if containsAllergen(view.Allergens, filter.ExcludeAllergens) {
+			continue
+		}
+		views = append(views, view)
+	}
+
+	sort.SliceStable(views, func(i, j int) bool {
+		pi, pj := position(views[i].MenuItem), position(views[j].MenuItem)
+		if pi != pj {
+			return pi < pj
+		}
+		return views[i].SortOrder < views[j].SortOrder
+	})
+
+	var result any = views
+	if filter.GroupByCategory {
+		result = groupByCategory(views, categories, position)
+	}
+
+	jsonFile, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return nil, myerrors.ErrFailMarshal
 	}
@@ -64,6 +413,27 @@ func (m *menuService) ServiceGetMenu() ([]byte, error) {
 	return jsonFile, nil
 }
 
+// groupByCategory splits sorted views into their categories, items without a known
+// category come last in a group with an empty category_id.
+func groupByCategory(views []models.MenuItemView, categories []models.Category, position func(models.MenuItem) int) []models.MenuCategoryGroup {
+	groups := []models.MenuCategoryGroup{}
+	current := -1
+	for _, view := range views {
+		if p := position(view.MenuItem); p != current || len(groups) == 0 {
+			current = p
+			group := models.MenuCategoryGroup{Items: []models.MenuItemView{}}
+			if p < len(categories) {
+				group.CategoryID = categories[p].ID
+				group.Name = categories[p].Name
+				group.SortOrder = categories[p].SortOrder
+			}
+			groups = append(groups, group)
+		}
+		groups[len(groups)-1].Items = append(groups[len(groups)-1].Items, view)
+	}
+	return groups
+}
+
 func (m *menuService) ServiceGetMenuID(id string) ([]byte, error) {
 	menu, err := m.menuRepo.GetMenuID(id)
 	if err == myerrors.ErrNotFound {
@@ -73,23 +443,458 @@ func (m *menuService) ServiceGetMenuID(id string) ([]byte, error) {
 		return nil, err
 	}
 
-	jsonFile, err := json.MarshalIndent(menu, "", "  ")
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := loadCategories(m.categoryRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := loadPriceList(m.priceRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	view := menuItemView(menu, book)
+	view.Price = prices.priceAt(menu, now)
+	view.Orderable = menuItemOrderable(menu, book, categories, now.In(m.location))
+
+	jsonFile, err := json.MarshalIndent(view, "", "  ")
 	if err != nil {
 		return nil, myerrors.ErrFailMarshal
 	}
 	return jsonFile, nil
 }
 
+func menuItemView(menuItem models.MenuItem, book recipeBook) models.MenuItemView {
+	servings := book.maxServings(menuItem)
+	allergens, nutrition := book.dietary(menuItem)
+	return models.MenuItemView{
+		MenuItem:    menuItem,
+		Available:   servings > 0,
+		MaxServings: servings,
+		Allergens:   allergens,
+		Nutrition:   nutrition,
+	}
+}
+
+// checkRecipe verifies the recipe against the inventory and the category before a menu item is saved.
+func (m *menuService) checkRecipe(menu models.MenuItem) error {
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return err
+	}
+	if err := book.checkIngredients(menu); err != nil {
+		return err
+	}
+	if err := book.checkUnits(menu); err != nil {
+		return err
+	}
+	if err := book.checkComponents(menu); err != nil {
+		return err
+	}
+
+	if menu.CategoryID != "" {
+		if _, err := m.categoryRepo.GetCategoryID(menu.CategoryID); err != nil {
+			slog.Error("Failed to validate menu item", "error", myerrors.ErrUnknownCategory, "category", menu.CategoryID)
+			return myerrors.ErrUnknownCategory
+		}
+	}
+	return nil
+}
+
 func (m *menuService) ServiceUpdateMenu(id string, newMenu []byte) error {
 	var menu models.MenuItem
 	if err := json.Unmarshal(newMenu, &menu); err != nil {
 		slog.Error("Failed to unmarshal", "error", err)
 		return myerrors.ErrFailUnmarshal
 	}
+	menu.DeletedAt = ""
 
 	if err := validation.CheckMenu(menu); err != nil {
 		return err
 	}
 
-	return m.menuRepo.UpdateMenu(id, menu)
+	if err := m.checkRecipe(menu); err != nil {
+		return err
+	}
+
+	current, err := m.menuRepo.GetMenuID(id)
+	if err != nil {
+		return err
+	}
+	if current.DeletedAt != "" {
+		slog.Error("Failed to update menu", "error", myerrors.ErrArchived, "product", id)
+		return myerrors.ErrArchived
+	}
+
+	prices, err := loadPriceList(m.priceRepo)
+	if err != nil {
+		return err
+	}
+
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	versions := newRecipeVersions(&current, &menu, book, now)
+	if err := m.menuRepo.UpdateMenu(id, menu); err != nil {
+		return err
+	}
+	if err := saveRecipeVersions(m.recipeRepo, versions); err != nil {
+		return err
+	}
+
+	if menu.Price != prices.priceAt(current, now) {
+		if err := recordBaseline(m.priceRepo, prices, current); err != nil {
+			return err
+		}
+		if _, err := recordPrice(m.priceRepo, id, menu.Price, now); err != nil {
+			return err
+		}
+	}
+	m.events.Publish(models.TopicMenu, models.EventMenuUpdated, id, menu)
+	return nil
+}
+
+// Retrieve the recipe versions of a menu item, oldest first, each with what changed
+// since the version before.
+func (m *menuService) ServiceGetRecipeVersions(id string) ([]byte, error) {
+	menuItem, err := m.menuRepo.GetMenuID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := book.versions[id]
+	if len(versions) == 0 {
+		versions = []models.RecipeVersion{recipeVersion(menuItem, 1, "")}
+	}
+
+	views := []models.RecipeVersionView{}
+	previous := models.RecipeVersion{}
+	for _, version := range versions {
+		views = append(views, models.RecipeVersionView{RecipeVersion: version, Changes: recipeChanges(previous, version)})
+		previous = version
+	}
+
+	jsonFile, err := json.MarshalIndent(views, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+type menuImportRow struct {
+	row  int
+	item models.MenuItem
+	err  error
+}
+
+// Import a JSON array or CSV of menu items. Every row is validated first and the
+// menu is written once, so either all rows are applied or none of them.
+func (m *menuService) ServiceImportMenu(data []byte, isCSV, dryRun, upsert bool) ([]byte, error) {
+	var rows []menuImportRow
+	var err error
+	if isCSV {
+		rows, err = decodeMenuCSV(data)
+	} else {
+		rows, err = decodeMenuJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, myerrors.ErrNoItems
+	}
+
+	menu, err := m.menuRepo.GetMenu()
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := loadRecipeBook(m.menuRepo, m.recipeRepo, m.inventoryRepo, m.unitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := m.categoryRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[string]bool)
+	for _, category := range categories {
+		categoryIDs[category.ID] = true
+	}
+
+	prices, err := loadPriceList(m.priceRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int)
+	for i, menuItem := range menu {
+		positions[menuItem.ID] = i
+	}
+
+	now := time.Now()
+	result := newImportResult(len(rows), dryRun, upsert)
+	imported := make(map[string]bool)
+	repriced := make(map[string]float64)
+	var baselines []models.MenuItem
+	var versions []models.RecipeVersion
+
+	for _, row := range rows {
+		row.item.DeletedAt = ""
+		if row.err == nil {
+			row.err = validation.CheckMenu(row.item)
+		}
+		if row.err == nil {
+			row.err = book.checkIngredients(row.item)
+		}
+		if row.err == nil {
+			row.err = book.checkUnits(row.item)
+		}
+		if row.err == nil {
+			row.err = book.checkComponents(row.item)
+		}
+		if row.err == nil && row.item.CategoryID != "" && !categoryIDs[row.item.CategoryID] {
+			row.err = myerrors.ErrUnknownCategory
+		}
+		if row.err == nil && imported[row.item.ID] {
+			row.err = myerrors.ErrDuplicateID
+		}
+		if row.err == nil {
+			if i, ok := positions[row.item.ID]; !ok {
+				versions = append(versions, newRecipeVersions(nil, &row.item, book, now)...)
+				menu = append(menu, row.item)
+				positions[row.item.ID] = len(menu) - 1
+				repriced[row.item.ID] = row.item.Price
+				result.Created++
+			} else if menu[i].DeletedAt != "" {
+				row.err = myerrors.ErrArchived
+			} else if upsert {
+				if row.item.Price != prices.priceAt(menu[i], now) {
+					repriced[row.item.ID] = row.item.Price
+					baselines = append(baselines, menu[i])
+				}
+				versions = append(versions, newRecipeVersions(&menu[i], &row.item, book, now)...)
+				menu[i] = row.item
+				result.Updated++
+			} else {
+				row.err = myerrors.ErrIDExist
+			}
+		}
+
+		if row.err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: row.row, ID: row.item.ID, Error: row.err.Error()})
+			continue
+		}
+		imported[row.item.ID] = true
+		book.menu[row.item.ID] = row.item
+	}
+
+	if len(result.Errors) == 0 && !dryRun {
+		if err := m.menuRepo.SaveMenu(menu); err != nil {
+			return nil, err
+		}
+		if err := saveRecipeVersions(m.recipeRepo, versions); err != nil {
+			return nil, err
+		}
+		for _, menuItem := range baselines {
+			if err := recordBaseline(m.priceRepo, prices, menuItem); err != nil {
+				return nil, err
+			}
+		}
+		for productID, price := range repriced {
+			if _, err := recordPrice(m.priceRepo, productID, price, now); err != nil {
+				return nil, err
+			}
+		}
+		m.events.Publish(models.TopicMenu, models.EventMenuImported, "", result)
+	}
+
+	return marshalImportResult(result)
+}
+
+func decodeMenuJSON(data []byte) ([]menuImportRow, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	rows := make([]menuImportRow, 0, len(raws))
+	for i, raw := range raws {
+		row := menuImportRow{row: i + 1}
+		if err := json.Unmarshal(raw, &row.item); err != nil {
+			row.err = myerrors.ErrInvalidJson
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeMenuCSV reads the layout produced by GET /menu?format=csv: lines with the same
+// product_id form one menu item. The first of its ingredients, components, slots and
+// schedules that is not empty has one line per element in dotted columns
+// ("ingredients.quantity"), the ones after it are written as JSON in a single column
+// ("slots") repeated on every line, repeated values are read once.
+func decodeMenuCSV(data []byte) ([]menuImportRow, error) {
+	lines, err := csvencoder.Decode(data)
+	if err != nil {
+		slog.Error("Failed to read csv", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	var rows []menuImportRow
+	var seen []map[string]bool
+	positions := make(map[string]int)
+	for i, line := range lines {
+		id := line["product_id"]
+		pos, ok := positions[id]
+		if !ok || id == "" {
+			row := menuImportRow{
+				row: i + 2,
+				item: models.MenuItem{
+					ID:          id,
+					Name:        line["name"],
+					Description: line["description"],
+					CategoryID:  line["category_id"],
+					Station:     line["station"],
+				},
+			}
+			if line["price"] != "" {
+				price, err := strconv.ParseFloat(line["price"], 64)
+				if err != nil {
+					row.err = myerrors.ErrInvalidPrice
+				}
+				row.item.Price = price
+			}
+			if line["sort_order"] != "" {
+				sortOrder, err := strconv.Atoi(line["sort_order"])
+				if err != nil && row.err == nil {
+					row.err = myerrors.ErrInvalidSortOrder
+				}
+				row.item.SortOrder = sortOrder
+			}
+			rows = append(rows, row)
+			seen = append(seen, make(map[string]bool))
+			pos = len(rows) - 1
+			positions[id] = pos
+		}
+		row := &rows[pos]
+
+		for column, into := range map[string]any{
+			"ingredients": &row.item.Ingredients,
+			"components":  &row.item.Components,
+			"slots":       &row.item.Slots,
+			"schedules":   &row.item.Schedules,
+		} {
+			if line[column] == "" || seen[pos][column] {
+				continue
+			}
+			seen[pos][column] = true
+			if err := json.Unmarshal([]byte(line[column]), into); err != nil && row.err == nil {
+				row.err = myerrors.ErrInvalidJson
+			}
+		}
+
+		if key := "ingredient|" + line["ingredients.ingredient_id"] + "|" + line["ingredients.quantity"] + "|" + line["ingredients.unit"]; key != "ingredient|||" && !seen[pos][key] {
+			seen[pos][key] = true
+			quantity, err := strconv.ParseFloat(line["ingredients.quantity"], 64)
+			if err != nil && row.err == nil {
+				row.err = myerrors.ErrInvalidQuantity
+			}
+			row.item.Ingredients = append(row.item.Ingredients, models.MenuItemIngredient{
+				IngredientID: line["ingredients.ingredient_id"],
+				Quantity:     quantity,
+				Unit:         line["ingredients.unit"],
+			})
+		}
+
+		if key := "component|" + line["components.product_id"]; key != "component|" && !seen[pos][key] {
+			seen[pos][key] = true
+			quantity, err := strconv.Atoi(line["components.quantity"])
+			if err != nil && row.err == nil {
+				row.err = myerrors.ErrInvalidQuantity
+			}
+			row.item.Components = append(row.item.Components, models.BundleComponent{
+				ProductID: line["components.product_id"],
+				Quantity:  quantity,
+			})
+		}
+
+		if key := "slot|" + line["slots.slot"]; key != "slot|" && !seen[pos][key] {
+			seen[pos][key] = true
+			quantity, err := strconv.Atoi(line["slots.quantity"])
+			if err != nil && row.err == nil {
+				row.err = myerrors.ErrInvalidQuantity
+			}
+			var options []string
+			if line["slots.options"] != "" {
+				options = strings.Split(line["slots.options"], ";")
+			}
+			row.item.Slots = append(row.item.Slots, models.BundleSlot{
+				Name:     line["slots.slot"],
+				Options:  options,
+				Quantity: quantity,
+			})
+		}
+
+		if key := "schedule|" + line["schedules.days"] + "|" + line["schedules.from"] + "|" + line["schedules.to"] + "|" + line["schedules.start_date"] + "|" + line["schedules.end_date"]; key != "schedule|||||" && !seen[pos][key] {
+			seen[pos][key] = true
+			var days []string
+			if line["schedules.days"] != "" {
+				days = strings.Split(line["schedules.days"], ";")
+			}
+			row.item.Schedules = append(row.item.Schedules, models.Schedule{
+				Days:      days,
+				From:      line["schedules.from"],
+				To:        line["schedules.to"],
+				StartDate: line["schedules.start_date"],
+				EndDate:   line["schedules.end_date"],
+			})
+		}
+	}
+	return rows, nil
+}
+
+func newImportResult(total int, dryRun, upsert bool) models.ImportResult {
+	mode := "create"
+	if upsert {
+		mode = "upsert"
+	}
+	return models.ImportResult{
+		DryRun: dryRun,
+		Mode:   mode,
+		Total:  total,
+		Errors: []models.ImportRowError{},
+	}
+}
+
+// marshalImportResult returns the report together with ErrImportRejected when any row failed.
+func marshalImportResult(result models.ImportResult) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+
+	if len(result.Errors) > 0 {
+		slog.Error("Import rejected", "errors", len(result.Errors))
+		return jsonFile, myerrors.ErrImportRejected
+	}
+	return jsonFile, nil
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/csvencoder"
+	"hot-coffee/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMenuCSVRoundTrip(t *testing.T) {
+	espresso := models.MenuItem{ID: "espresso", Name: "Espresso", Description: "Short and strong", Price: 2.5, Ingredients: []models.MenuItemIngredient{{IngredientID: "espresso_shot", Quantity: 1}}, RecipeVersion: 1}
+	latte := models.MenuItem{ID: "latte", Name: "Latte", Description: "Espresso with milk", Price: 3.5, Ingredients: []models.MenuItemIngredient{{IngredientID: "espresso_shot", Quantity: 1}, {IngredientID: "milk", Quantity: 0.2, Unit: "l"}}, Station: "bar", SortOrder: 2, RecipeVersion: 1}
+	croissant := models.MenuItem{ID: "croissant", Name: "Croissant", Description: "Butter croissant", Price: 2, Ingredients: []models.MenuItemIngredient{{IngredientID: "croissant", Quantity: 1}}, Station: "food", RecipeVersion: 1}
+
+	tests := []struct {
+		name string
+		menu []models.MenuItem
+	}{
+		{
+			name: "items with ingredients",
+			menu: []models.MenuItem{espresso, latte},
+		},
+		{
+			name: "item with schedules",
+			menu: []models.MenuItem{espresso, {ID: "breakfast_latte", Name: "Breakfast latte", Description: "Only in the morning", Price: 3, Ingredients: latte.Ingredients, Schedules: []models.Schedule{{Days: []string{"mon", "tue"}, From: "07:00", To: "11:00"}, {StartDate: "2026-01-01", EndDate: "2026-03-31"}}, RecipeVersion: 1}},
+		},
+		{
+			name: "bundle with components and slots",
+			menu: []models.MenuItem{espresso, latte, croissant, {ID: "breakfast", Name: "Breakfast", Description: "Croissant and a drink", Price: 5, Components: []models.BundleComponent{{ProductID: "croissant", Quantity: 1}}, Slots: []models.BundleSlot{{Name: "drink", Options: []string{"espresso", "latte"}, Quantity: 1}, {Name: "side", Options: []string{"croissant"}, Quantity: 2}}, RecipeVersion: 1}},
+		},
+		{
+			name: "bundle with only slots and schedules",
+			menu: []models.MenuItem{espresso, latte, {ID: "duo", Name: "Duo", Description: "Two drinks", Price: 6, Slots: []models.BundleSlot{{Name: "first", Options: []string{"espresso", "latte"}, Quantity: 1}, {Name: "second", Options: []string{"latte"}, Quantity: 1}}, Schedules: []models.Schedule{{Days: []string{"sat", "sun"}}}, RecipeVersion: 1}},
+		},
+		{
+			name: "bundle with ingredients, components, slots and schedules",
+			menu: []models.MenuItem{espresso, latte, croissant, {ID: "brunch", Name: "Brunch", Description: "Everything", Price: 9.75, Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 50}}, Components: []models.BundleComponent{{ProductID: "croissant", Quantity: 2}}, Slots: []models.BundleSlot{{Name: "drink", Options: []string{"espresso", "latte"}, Quantity: 1}}, Schedules: []models.Schedule{{From: "10:00", To: "14:00"}}, Station: "food", RecipeVersion: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataDir(t, map[string]any{
+				"menu_items.json": tt.menu,
+				"inventory_item.json": []models.InventoryItem{
+					{IngredientID: "espresso_shot", Name: "Espresso shot", Quantity: 500, Unit: "shots"},
+					{IngredientID: "milk", Name: "Milk", Quantity: 5000, Unit: "ml"},
+					{IngredientID: "croissant", Name: "Croissant", Quantity: 20, Unit: "pcs"},
+				},
+			})
+
+			menuRepo := dal.NewMenuRepository("menu_items.json")
+			recipeRepo := dal.NewRecipeVersionRepository("recipe_versions.json")
+			m := NewMenuService(menuRepo, recipeRepo, dal.NewOrderRepository("orders.json"), dal.NewInventoryRepository("inventory_item.json"), dal.NewUnitRepository("units.json"), dal.NewCategoryRepository("categories.json"), dal.NewPriceRepository("menu_prices.json"), nil, nil, nil, nil, nil, NewEventBus(10), time.UTC)
+
+			exported, err := m.ServiceGetMenu(MenuFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var csv bytes.Buffer
+			if err := csvencoder.Encode(&csv, bytes.NewReader(exported)); err != nil {
+				t.Fatal(err)
+			}
+
+			if result, err := m.ServiceImportMenu(csv.Bytes(), true, false, true); err != nil {
+				t.Fatalf("ServiceImportMenu() error = %v\n%s\ncsv:\n%s", err, result, csv.String())
+			}
+
+			menu, err := menuRepo.GetMenu()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(menu, tt.menu) {
+				t.Errorf("menu after the round trip = %+v, want %+v\ncsv:\n%s", menu, tt.menu, csv.String())
+			}
+
+			versions, err := recipeRepo.GetRecipeVersions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 0 {
+				t.Errorf("round trip recorded %d recipe versions, want none", len(versions))
+			}
+		})
+	}
+}
@@ -8,44 +8,93 @@ import (
 	"hot-coffee/internal/utils/validation"
 	"hot-coffee/models"
 	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
 
 type OrderService interface {
-	ServiceGetOrder() ([]byte, error)
+	ServiceGetOrder(includeArchived bool) ([]byte, error)
 	ServiceGetOrderID(id string) ([]byte, error)
 	ServicePostOrder(newOrderByte []byte) error
 	ServicePostOrderClose(id string) error
 	ServicePutOrderID(id string, newOrderByte []byte) error
 	ServiceDeleteOrder(id string) error
+	ServiceRestoreOrder(id string) error
+	ServiceRefundOrder(id string) error
+	UpdateOrders(update func() error) error
 }
 
 type orderService struct {
-	orderRepo dal.OrderRepository
-	menuRepo  dal.MenuRepository
-	inventory dal.InventoryRepository
+	orderRepo    dal.OrderRepository
+	menuRepo     dal.MenuRepository
+	recipeRepo   dal.RecipeVersionRepository
+	customerRepo dal.CustomerRepository
+	inventory    dal.InventoryRepository
+	unitRepo     dal.UnitRepository
+	categoryRepo dal.CategoryRepository
+	priceRepo    dal.PriceRepository
+	ruleRepo     dal.LoyaltyRuleRepository
+	loyaltyRepo  dal.LoyaltyTransactionRepository
+	cardRepo     dal.GiftCardRepository
+	cardLogRepo  dal.GiftCardTransactionRepository
+	ledger       StockLedger
+	events       EventBus
+	location     *time.Location
+
+	// mu is held while orders are read and written back, by this service and through
+	// UpdateOrders by the others changing orders, so no change overwrites another.
+	// It is taken before the ledger's.
+	mu sync.Mutex
 }
 
-func NewOrderService(orderRepo dal.OrderRepository, menuRepo dal.MenuRepository, inventoryRepo dal.InventoryRepository) OrderService {
+func NewOrderService(orderRepo dal.OrderRepository, menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, customerRepo dal.CustomerRepository, inventoryRepo dal.InventoryRepository, unitRepo dal.UnitRepository, categoryRepo dal.CategoryRepository, priceRepo dal.PriceRepository, ruleRepo dal.LoyaltyRuleRepository, loyaltyRepo dal.LoyaltyTransactionRepository, cardRepo dal.GiftCardRepository, cardLogRepo dal.GiftCardTransactionRepository, ledger StockLedger, events EventBus, location *time.Location) OrderService {
 	return &orderService{
-		orderRepo: orderRepo,
-		menuRepo:  menuRepo,
-		inventory: inventoryRepo,
+		orderRepo:    orderRepo,
+		menuRepo:     menuRepo,
+		recipeRepo:   recipeRepo,
+		customerRepo: customerRepo,
+		inventory:    inventoryRepo,
+		unitRepo:     unitRepo,
+		categoryRepo: categoryRepo,
+		priceRepo:    priceRepo,
+		ruleRepo:     ruleRepo,
+		loyaltyRepo:  loyaltyRepo,
+		cardRepo:     cardRepo,
+		cardLogRepo:  cardLogRepo,
+		ledger:       ledger,
+		events:       events,
+		location:     location,
 	}
 }
 
+// UpdateOrders runs update, which reads orders and writes them back, while no other
+// change to the orders runs.
+func (s *orderService) UpdateOrders(update func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return update()
+}
+
 // CHECK JSON STRUCTURE DOES IT HAVE FIELD IN STRUCT
 // SET CREATED TIME AND STATUS
 
-// Retrieve all orders.
-func (s *orderService) ServiceGetOrder() ([]byte, error) {
-	ordersStruct, err := s.orderRepo.GetOrder()
+// Retrieve all orders, archived ones only when includeArchived is set.
+func (s *orderService) ServiceGetOrder(includeArchived bool) ([]byte, error) {
+	orders, err := s.orderRepo.GetOrder()
 	if err != nil {
 		return nil, err
 	}
 
+	ordersStruct := []models.Order{}
+	for _, order := range orders {
+		if order.DeletedAt == "" || includeArchived {
+			ordersStruct = append(ordersStruct, order)
+		}
+	}
+
 	jsonFile, err := json.MarshalIndent(ordersStruct, "", "  ")
 	if err != nil {
 		return nil, err
@@ -69,6 +118,9 @@ func (s *orderService) ServiceGetOrderID(id string) ([]byte, error) {
 
 // Create a new order.
 func (s *orderService) ServicePostOrder(newOrderByte []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var newOrder models.Order
 	if err := json.Unmarshal(newOrderByte, &newOrder); err != nil {
 		slog.Error("Failed to unmarshal", "error", err)
@@ -79,6 +131,10 @@ func (s *orderService) ServicePostOrder(newOrderByte []byte) error {
 		return err
 	}
 
+	if err := s.linkCustomer(&newOrder); err != nil {
+		return err
+	}
+
 	items := utils.AggregateOrderItems(newOrder.Items)
 
 	menu, err := s.menuRepo.GetMenu()
@@ -94,7 +150,7 @@ func (s *orderService) ServicePostOrder(newOrderByte []byte) error {
 		isFound := false
 
 		for _, menuItem := range menu {
-			if product == menuItem.ID {
+			if product == menuItem.ID && menuItem.DeletedAt == "" {
 				amountOfExists++
 				isFound = true
 			}
@@ -116,31 +172,32 @@ func (s *orderService) ServicePostOrder(newOrderByte []byte) error {
 		return myerrors.ErrAbsentItem
 	}
 
-	tempInventory, err := s.inventory.GetInventory()
+	book, err := loadRecipeBook(s.menuRepo, s.recipeRepo, s.inventory, s.unitRepo)
 	if err != nil {
 		return err // ok
 	}
+	tempInventory := book.inventory
+
+	if err := s.checkSchedules(items, book, time.Now().In(s.location)); err != nil {
+		return err
+	}
 
 	for i := 0; i < len(items); i++ {
-		item := items[i]
-		menuItem, err := s.menuRepo.GetMenuID(item.ProductID)
+		items[i].RecipeVersion = book.menu[items[i].ProductID].RecipeVersion
+		items[i].Made = false
+		items[i].MadeStations = nil
+		requiredIngredients, err := book.orderItemRequirements(items[i], time.Time{})
 		if err != nil {
-			return err // ok
+			return err
 		}
 
 		hasEnoughIngredients := true
-		requiredIngredients := make(map[string]float64)
-
-		for _, ingredient := range menuItem.Ingredients {
-			inventory := utils.GetInventoryID(ingredient.IngredientID, tempInventory)
-
-			requiredQty := float64(item.Quantity) * ingredient.Quantity
+		for ingID, requiredQty := range requiredIngredients {
+			inventory := utils.GetInventoryID(ingID, tempInventory)
 			if requiredQty > inventory.Quantity {
 				hasEnoughIngredients = false
 				break
 			}
-
-			requiredIngredients[ingredient.IngredientID] += requiredQty
 		}
 
 		if !hasEnoughIngredients {
@@ -157,73 +214,222 @@ func (s *orderService) ServicePostOrder(newOrderByte []byte) error {
 		return myerrors.ErrEmptyOrder // ok
 	}
 
+	now := time.Now()
 	newOrder.Items = items
+	if err := s.priceOrder(&newOrder, book, now); err != nil {
+		return err
+	}
+
+	program, err := loadLoyaltyProgram(s.ruleRepo, s.loyaltyRepo)
+	if err != nil {
+		return err
+	}
+	newOrder.Discount = 0
+	spent, err := program.redeem(&newOrder, book)
+	if err != nil {
+		return err
+	}
+
+	newOrder.GiftCards = nil
 	newOrder.ID = uuid.RandStringBytesMask()
 	slog.Info(newOrder.ID)
 	newOrder.Status = "open"
-	newOrder.CreatedAt = time.Now().Format(time.RFC3339)
+	newOrder.CreatedAt = now.Format(time.RFC3339)
+	newOrder.ReadyAt = ""
+	newOrder.ClosedAt = ""
+	newOrder.RefundedAt = ""
+	newOrder.DeletedAt = ""
 
 	err = s.orderRepo.CreateOrder(newOrder)
 	if err != nil {
 		return err // ok
 	}
+
+	for i := range spent {
+		spent[i].OrderID = newOrder.ID
+	}
+	if err := saveLoyaltyTransactions(s.loyaltyRepo, spent, now); err != nil {
+		return err
+	}
+	s.publish(models.EventOrderCreated, newOrder.ID)
 	return nil
 }
 
-// Close an order.
-func (s *orderService) ServicePostOrderClose(id string) error {
-	tempInventory, err := s.inventory.GetInventory()
+// publish sends an order event with the order as it is saved now.
+func (s *orderService) publish(eventType, id string) {
+	order, err := s.orderRepo.GetOrderID(id)
 	if err != nil {
-		return err // ok
+		slog.Error("Failed to publish order event", "error", err, "id", id, "type", eventType)
+		return
 	}
+	s.events.Publish(models.TopicOrders, eventType, id, order)
+}
 
-	orderr, err := s.orderRepo.GetOrderID(id)
-	items := orderr.Items
+// linkCustomer checks the customer an order is linked to, without a customer_name
+// the order takes the customer's name.
+func (s *orderService) linkCustomer(order *models.Order) error {
+	if order.CustomerID == "" {
+		return nil
+	}
 
-	for i := 0; i < len(items); i++ {
-		item := items[i]
-		menuItem, err := s.menuRepo.GetMenuID(item.ProductID)
-		if err != nil {
-			return err // ok
-		}
+	customer, err := s.customerRepo.GetCustomerID(order.CustomerID)
+	if err == myerrors.ErrNotFound {
+		slog.Error("Failed to link customer", "error", myerrors.ErrUnknownCustomer, "customer", order.CustomerID)
+		return myerrors.ErrUnknownCustomer
+	}
+	if err != nil {
+		return err
+	}
 
-		hasEnoughIngredients := true
-		requiredIngredients := make(map[string]float64)
+	if order.CustomerName == "" {
+		order.CustomerName = customer.Name
+	}
+	return nil
+}
 
-		for _, ingredient := range menuItem.Ingredients {
-			inventory := utils.GetInventoryID(ingredient.IngredientID, tempInventory)
+// priceOrder sets the price effective at t on every line and the order total.
+func (s *orderService) priceOrder(order *models.Order, book recipeBook, t time.Time) error {
+	prices, err := loadPriceList(s.priceRepo)
+	if err != nil {
+		return err
+	}
 
-			requiredQty := float64(item.Quantity) * ingredient.Quantity
-			if requiredQty > inventory.Quantity {
-				hasEnoughIngredients = false
-				break
+	order.Total = 0
+	for i, item := range order.Items {
+		price := prices.priceAt(book.menu[item.ProductID], t)
+		order.Items[i].Price = &price
+		order.Total += price * float64(item.Quantity)
+	}
+	order.Total = round2(order.Total)
+	return nil
+}
+
+// checkSchedules rejects items, or bundle components, that cannot be ordered at t.
+func (s *orderService) checkSchedules(items []models.OrderItem, book recipeBook, t time.Time) error {
+	categories, err := loadCategories(s.categoryRepo)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		menuItem := book.menu[item.ProductID]
+		components, err := book.components(menuItem, item.Choices)
+		if err != nil {
+			return err
+		}
+
+		productIDs := []string{item.ProductID}
+		for _, component := range components {
+			productIDs = append(productIDs, component.ProductID)
+		}
+		for _, productID := range productIDs {
+			if !scheduledOpen(book.menu[productID], categories, t) {
+				slog.Error("Failed to create order", "error", myerrors.ErrNotOrderableNow, "product", productID)
+				return myerrors.ErrNotOrderableNow
 			}
+		}
+	}
+	return nil
+}
 
-			requiredIngredients[ingredient.IngredientID] += requiredQty
+// Close an order.
+func (s *orderService) ServicePostOrderClose(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var order models.Order
+	var book recipeBook
+	// The order is checked, the stock taken and the order closed in one ledger step,
+	// so two closes of the same order can not both take its ingredients.
+	err := s.ledger.Record(func() ([]models.InventoryMovement, error) {
+		var err error
+		order, err = s.orderRepo.GetOrderID(id)
+		if err != nil {
+			return nil, err
+		}
+		if order.Status == "closed" || order.Status == "refunded" {
+			slog.Error("Failed to close order", "error", myerrors.ErrOrderClosed)
+			return nil, myerrors.ErrOrderClosed
+		}
+		if order.DeletedAt != "" {
+			slog.Error("Failed to close order", "error", myerrors.ErrArchived, "id", id)
+			return nil, myerrors.ErrArchived
 		}
 
-		if !hasEnoughIngredients {
-			return myerrors.ErrNotEnoughIngridients
-		} else {
+		book, err = loadRecipeBook(s.menuRepo, s.recipeRepo, s.inventory, s.unitRepo)
+		if err != nil {
+			return nil, err // ok
+		}
+		tempInventory := book.inventory
 
-			for ingID, qty := range requiredIngredients {
-				tempInventory = utils.DecreaseTemporaryStock(ingID, qty, tempInventory)
+		// Orders are made with the recipes they were placed with.
+		createdAt, _ := time.Parse(time.RFC3339, order.CreatedAt)
+		requiredIngredients := make(map[string]float64)
+		for _, item := range order.Items {
+			required, err := book.orderItemRequirements(item, createdAt)
+			if err != nil {
+				return nil, err
 			}
-			for _, invitem := range tempInventory {
-				err = s.inventory.UpdateInventory(invitem.IngredientID, invitem)
+			for ingID, qty := range required {
+				requiredIngredients[ingID] += qty
 			}
 		}
-	}
-	err = s.orderRepo.CloseOrder(id)
+
+		ingredientIDs := make([]string, 0, len(requiredIngredients))
+		for ingID := range requiredIngredients {
+			ingredientIDs = append(ingredientIDs, ingID)
+		}
+		sort.Strings(ingredientIDs)
+
+		var movements []models.InventoryMovement
+		for _, ingID := range ingredientIDs {
+			inventory := utils.GetInventoryID(ingID, tempInventory)
+			if requiredIngredients[ingID] > inventory.Quantity {
+				return nil, myerrors.ErrNotEnoughIngridients
+			}
+
+			movements = append(movements, models.InventoryMovement{
+				IngredientID: ingID,
+				Delta:        -requiredIngredients[ingID],
+				Reason:       models.MovementSale,
+				ReferenceID:  id,
+			})
+		}
+
+		if err := changeInventory(s.inventory, movements); err != nil {
+			return nil, err
+		}
+		if err := s.orderRepo.CloseOrder(id); err != nil {
+			// The order stays open, so the stock it took goes back.
+			undoInventory(s.inventory, movements)
+			return nil, err
+		}
+		return movements, nil
+	})
 	if err != nil {
 		return err
 	}
 
+	// Customers earn loyalty points and stamps with the orders they pay for.
+	if order.CustomerID != "" {
+		program, err := loadLoyaltyProgram(s.ruleRepo, s.loyaltyRepo)
+		if err != nil {
+			return err
+		}
+		if err := saveLoyaltyTransactions(s.loyaltyRepo, program.earn(order, book), time.Now()); err != nil {
+			return err
+		}
+	}
+
+	s.publish(models.EventOrderClosed, id)
 	return nil
 }
 
 // Update an existing order.
 func (s *orderService) ServicePutOrderID(id string, newOrderByte []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var newOrder models.Order
 	if err := json.Unmarshal(newOrderByte, &newOrder); err != nil {
 		slog.Error("Failed to unmarshal", "error", err)
@@ -234,30 +440,212 @@ func (s *orderService) ServicePutOrderID(id string, newOrderByte []byte) error {
 		return err
 	}
 
+	if err := s.linkCustomer(&newOrder); err != nil {
+		return err
+	}
+
 	checkOrder, err := s.orderRepo.GetOrderID(id)
 	if err != nil {
 		return err
 	}
-	if checkOrder.Status == "closed" {
+	if checkOrder.Status == "closed" || checkOrder.Status == "refunded" {
 		slog.Error("Failed to update: status is closed", "id", id)
 		return myerrors.ErrOrderClosed
 	}
+	if checkOrder.DeletedAt != "" {
+		slog.Error("Failed to update: order is archived", "id", id)
+		return myerrors.ErrArchived
+	}
+
+	book, err := loadRecipeBook(s.menuRepo, s.recipeRepo, s.inventory, s.unitRepo)
+	if err != nil {
+		return err
+	}
+
+	// Lines of products already in the order keep their pinned recipe and whether
+	// they were made.
+	pinned := make(map[string]int)
+	made := make(map[string]models.OrderItem)
+	for _, item := range checkOrder.Items {
+		pinned[item.ProductID] = item.RecipeVersion
+		made[item.ProductID] = item
+	}
+	for i, item := range newOrder.Items {
+		if menuItem, ok := book.menu[item.ProductID]; !ok || menuItem.DeletedAt != "" {
+			slog.Error("Failed to update order", "error", myerrors.ErrAbsentItem, "id", id, "product", item.ProductID)
+			return myerrors.ErrAbsentItem
+		}
+		version, ok := pinned[item.ProductID]
+		if !ok {
+			version = book.menu[item.ProductID].RecipeVersion
+		}
+		newOrder.Items[i].RecipeVersion = version
+		newOrder.Items[i].Made = made[item.ProductID].Made
+		newOrder.Items[i].MadeStations = made[item.ProductID].MadeStations
+		if _, err := book.orderItemRequirements(newOrder.Items[i], time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, checkOrder.CreatedAt)
+	if err != nil {
+		createdAt = time.Now()
+	}
+	if err := s.priceOrder(&newOrder, book, createdAt); err != nil {
+		return err
+	}
+
+	// Rewards are spent when the order is placed, an update keeps them and the
+	// discount follows the new lines.
+	if len(checkOrder.Redemptions) > 0 {
+		newOrder.CustomerID = checkOrder.CustomerID
+	}
+	newOrder.Redemptions = checkOrder.Redemptions
+	program, err := loadLoyaltyProgram(s.ruleRepo, s.loyaltyRepo)
+	if err != nil {
+		return err
+	}
+	if err := program.discountOrder(&newOrder, book); err != nil {
+		return err
+	}
+
+	newOrder.GiftCards = checkOrder.GiftCards
+	if newOrder.Total < giftCardPaid(newOrder) {
+		slog.Error("Failed to update order", "error", myerrors.ErrBelowPaid, "id", id)
+		return myerrors.ErrBelowPaid
+	}
 
 	newOrder.ID = checkOrder.ID
 	newOrder.Status = checkOrder.Status
 	newOrder.CreatedAt = checkOrder.CreatedAt
+	newOrder.ReadyAt = ""
+	if allMade(newOrder) {
+		newOrder.ReadyAt = checkOrder.ReadyAt
+	}
+	newOrder.ClosedAt = ""
+	newOrder.RefundedAt = ""
+	newOrder.DeletedAt = ""
 	err = s.orderRepo.UpdateOrder(id, newOrder)
 	if err != nil {
 		return err
 	}
+	s.publish(models.EventOrderUpdated, id)
 	return nil
 }
 
-// Delete an order.
+// Archive an order, it stays in the reports. The rewards an open order spent are given
+// back and what was paid on it with gift cards goes back on the cards.
 func (s *orderService) ServiceDeleteOrder(id string) error {
-	err := s.orderRepo.DeleteOrder(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.GetOrderID(id)
+	if err != nil {
+		return err
+	}
+	if order.DeletedAt != "" {
+		slog.Error("Failed to delete order", "error", myerrors.ErrArchived, "id", id)
+		return myerrors.ErrArchived
+	}
+
+	now := time.Now()
+	paid := order
+	order.DeletedAt = now.Format(time.RFC3339)
+	if order.Status == "open" {
+		order.GiftCards = nil
+	}
+	if err := s.orderRepo.UpdateOrder(id, order); err != nil {
+		return err
+	}
+	if err := releaseRewards(s.ruleRepo, s.loyaltyRepo, order, now); err != nil {
+		return err
+	}
+	if paid.Status == "open" {
+		if err := refundGiftCards(s.cardRepo, s.cardLogRepo, paid, now); err != nil {
+			return err
+		}
+	}
+	s.publish(models.EventOrderDeleted, id)
+	return nil
+}
+
+// Restore an archived order. An open order spends the rewards it gave back on archive
+// again, the customer must still have them.
+func (s *orderService) ServiceRestoreOrder(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.GetOrderID(id)
 	if err != nil {
 		return err
 	}
+	if order.DeletedAt == "" {
+		return nil
+	}
+
+	var spent []models.LoyaltyTransaction
+	if order.Status == "open" && len(order.Redemptions) > 0 {
+		program, err := loadLoyaltyProgram(s.ruleRepo, s.loyaltyRepo)
+		if err != nil {
+			return err
+		}
+		// Orders archived without giving their rewards back still hold them.
+		if len(program.reverse(id)) == 0 {
+			if spent, err = program.respend(order); err != nil {
+				return err
+			}
+		}
+	}
+
+	order.DeletedAt = ""
+	if err := s.orderRepo.UpdateOrder(id, order); err != nil {
+		return err
+	}
+	if err := saveLoyaltyTransactions(s.loyaltyRepo, spent, time.Now()); err != nil {
+		return err
+	}
+	s.publish(models.EventOrderRestored, id)
+	return nil
+}
+
+// Refund a closed order: the loyalty points and stamps it earned are taken back, the
+// rewards spent on it are given back and so is what was paid with gift cards. Its
+// stock stays used.
+func (s *orderService) ServiceRefundOrder(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.orderRepo.GetOrderID(id)
+	if err != nil {
+		return err
+	}
+	if order.DeletedAt != "" {
+		slog.Error("Failed to refund order", "error", myerrors.ErrArchived, "id", id)
+		return myerrors.ErrArchived
+	}
+	if order.Status != "closed" {
+		slog.Error("Failed to refund order", "error", myerrors.ErrOrderNotClosed, "id", id, "status", order.Status)
+		return myerrors.ErrOrderNotClosed
+	}
+
+	program, err := loadLoyaltyProgram(s.ruleRepo, s.loyaltyRepo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	order.Status = "refunded"
+	order.RefundedAt = now.Format(time.RFC3339)
+	if err := s.orderRepo.UpdateOrder(id, order); err != nil {
+		return err
+	}
+
+	if err := refundGiftCards(s.cardRepo, s.cardLogRepo, order, now); err != nil {
+		return err
+	}
+	if err := saveLoyaltyTransactions(s.loyaltyRepo, program.reverse(id), now); err != nil {
+		return err
+	}
+	s.publish(models.EventOrderRefunded, id)
 	return nil
 }
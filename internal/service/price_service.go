@@ -0,0 +1,125 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PriceService interface {
+	ServiceGetPrices(productID string) ([]byte, error)
+	ServiceSchedulePrice(productID string, newPrice []byte) ([]byte, error)
+	ServiceCancelPrice(productID, priceID string) error
+}
+
+type priceService struct {
+	priceRepo dal.PriceRepository
+	menuRepo  dal.MenuRepository
+}
+
+func NewPriceService(priceRepo dal.PriceRepository, menuRepo dal.MenuRepository) PriceService {
+	return &priceService{
+		priceRepo: priceRepo,
+		menuRepo:  menuRepo,
+	}
+}
+
+// Retrieve the price history of a menu item, scheduled changes included.
+func (p *priceService) ServiceGetPrices(productID string) ([]byte, error) {
+	menuItem, err := p.menuRepo.GetMenuID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := loadPriceList(p.priceRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	history := models.PriceHistory{
+		ProductID:    productID,
+		CurrentPrice: prices.priceAt(menuItem, time.Now()),
+		Prices:       prices.changes[productID],
+	}
+	if history.Prices == nil {
+		history.Prices = []models.PriceChange{}
+	}
+
+	jsonFile, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Schedule a price change, without effective_from it takes effect right away.
+func (p *priceService) ServiceSchedulePrice(productID string, newPrice []byte) ([]byte, error) {
+	var change models.PriceChange
+	if err := json.Unmarshal(newPrice, &change); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	if change.Price < 0 {
+		slog.Error("Validation failed: Price field must be >=0")
+		return nil, myerrors.ErrPriceRequired
+	}
+
+	now := time.Now()
+	from := now
+	if change.EffectiveFrom != "" {
+		t, err := time.Parse(time.RFC3339, change.EffectiveFrom)
+		if err != nil || t.Before(now.Add(-time.Minute)) {
+			slog.Error("Validation failed: Effective from must be a future RFC3339 time", "effective from", change.EffectiveFrom)
+			return nil, myerrors.ErrInvalidEffectiveFrom
+		}
+		from = t
+	}
+
+	menuItem, err := p.menuRepo.GetMenuID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := loadPriceList(p.priceRepo)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordBaseline(p.priceRepo, prices, menuItem); err != nil {
+		return nil, err
+	}
+
+	change, err = recordPrice(p.priceRepo, productID, change.Price, from)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(change, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Cancel a scheduled price change, changes already in effect stay in the history.
+func (p *priceService) ServiceCancelPrice(productID, priceID string) error {
+	change, err := p.priceRepo.GetPriceID(priceID)
+	if err != nil {
+		return err
+	}
+	if change.ProductID != productID {
+		return myerrors.ErrNotFound
+	}
+	if !effectiveFrom(change).After(time.Now()) {
+		slog.Error("Failed to cancel price", "error", myerrors.ErrPriceInEffect, "price", priceID)
+		return myerrors.ErrPriceInEffect
+	}
+
+	return p.priceRepo.DeletePrice(priceID)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"sort"
+	"time"
+)
+
+// priceList tells which price of a menu item is effective at a given time. Menu items
+// without recorded changes keep the price stored on the item.
+type priceList struct {
+	changes map[string][]models.PriceChange
+}
+
+func loadPriceList(priceRepo dal.PriceRepository) (priceList, error) {
+	prices, err := priceRepo.GetPrices()
+	if err != nil {
+		return priceList{}, err
+	}
+
+	changes := make(map[string][]models.PriceChange)
+	for _, price := range prices {
+		changes[price.ProductID] = append(changes[price.ProductID], price)
+	}
+	for _, list := range changes {
+		sortPriceChanges(list)
+	}
+
+	return priceList{changes: changes}, nil
+}
+
+func (p priceList) priceAt(menuItem models.MenuItem, t time.Time) float64 {
+	price := menuItem.Price
+	for _, change := range p.changes[menuItem.ID] {
+		if effectiveFrom(change).After(t) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// recordBaseline keeps the price a menu item has now as the first entry of its history,
+// effective since always, when nothing was recorded for it yet. Items created before
+// the history was kept would otherwise take their next price back to before it.
+func recordBaseline(priceRepo dal.PriceRepository, prices priceList, menuItem models.MenuItem) error {
+	if len(prices.changes[menuItem.ID]) > 0 {
+		return nil
+	}
+	_, err := recordPrice(priceRepo, menuItem.ID, menuItem.Price, time.Time{})
+	return err
+}
+
+// recordPrice keeps a price change of a menu item in the history.
+func recordPrice(priceRepo dal.PriceRepository, productID string, price float64, from time.Time) (models.PriceChange, error) {
+	change := models.PriceChange{
+		ID:            uuid.NewID("price"),
+		ProductID:     productID,
+		Price:         price,
+		EffectiveFrom: from.Format(time.RFC3339),
+		CreatedAt:     time.Now().Format(time.RFC3339),
+	}
+	return change, priceRepo.CreatePrice(change)
+}
+
+func effectiveFrom(change models.PriceChange) time.Time {
+	t, _ := time.Parse(time.RFC3339, change.EffectiveFrom)
+	return t
+}
+
+func sortPriceChanges(changes []models.PriceChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return effectiveFrom(changes[i]).Before(effectiveFrom(changes[j]))
+	})
+}
+
+// orderPrice is the unit price an order line was sold at, orders created before
+// prices were recorded on them fall back to the menu price.
+func orderPrice(item models.OrderItem, menuItem models.MenuItem) float64 {
+	if item.Price != nil {
+		return *item.Price
+	}
+	return menuItem.Price
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PurchaseOrderService interface {
+	ServiceGetPurchaseOrders(status string) ([]byte, error)
+	ServiceGetPurchaseOrderID(id string) ([]byte, error)
+	ServiceCreatePurchaseOrder(newPurchaseOrder []byte) ([]byte, error)
+	ServiceUpdatePurchaseOrder(id string, newPurchaseOrder []byte) error
+	ServiceDeletePurchaseOrder(id string) error
+	ServiceSendPurchaseOrder(id string) error
+	ServiceReceivePurchaseOrder(id string, receipt []byte) ([]byte, error)
+}
+
+type purchaseOrderService struct {
+	purchaseOrderRepo dal.PurchaseOrderRepository
+	supplierRepo      dal.SupplierRepository
+	ledger            StockLedger
+}
+
+func NewPurchaseOrderService(purchaseOrderRepo dal.PurchaseOrderRepository, supplierRepo dal.SupplierRepository, ledger StockLedger) PurchaseOrderService {
+	return &purchaseOrderService{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		ledger:            ledger,
+	}
+}
+
+func (p *purchaseOrderService) ServiceGetPurchaseOrders(status string) ([]byte, error) {
+	purchaseOrders, err := p.purchaseOrderRepo.GetPurchaseOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []models.PurchaseOrder{}
+	for _, purchaseOrder := range purchaseOrders {
+		if status == "" || purchaseOrder.Status == status {
+			filtered = append(filtered, purchaseOrder)
+		}
+	}
+
+	return marshalPurchaseOrder(filtered)
+}
+
+func (p *purchaseOrderService) ServiceGetPurchaseOrderID(id string) ([]byte, error) {
+	purchaseOrder, err := p.purchaseOrderRepo.GetPurchaseOrderID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalPurchaseOrder(purchaseOrder)
+}
+
+// Create a draft purchase order, pack sizes and prices come from the supplier.
+func (p *purchaseOrderService) ServiceCreatePurchaseOrder(newPurchaseOrder []byte) ([]byte, error) {
+	purchaseOrder, err := p.decodePurchaseOrder(newPurchaseOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	purchaseOrder.ID = uuid.NewID("po")
+	purchaseOrder.Status = models.PurchaseOrderDraft
+	purchaseOrder.CreatedAt = time.Now().Format(time.RFC3339)
+
+	if err := p.purchaseOrderRepo.CreatePurchaseOrder(purchaseOrder); err != nil {
+		return nil, err
+	}
+
+	return marshalPurchaseOrder(purchaseOrder)
+}
+
+// Update the lines or supplier of a draft purchase order.
+func (p *purchaseOrderService) ServiceUpdatePurchaseOrder(id string, newPurchaseOrder []byte) error {
+	current, err := p.purchaseOrderRepo.GetPurchaseOrderID(id)
+	if err != nil {
+		return err
+	}
+	if current.Status != models.PurchaseOrderDraft {
+		slog.Error("Failed to update purchase order", "error", myerrors.ErrPurchaseOrderState, "status", current.Status)
+		return myerrors.ErrPurchaseOrderState
+	}
+
+	purchaseOrder, err := p.decodePurchaseOrder(newPurchaseOrder)
+	if err != nil {
+		return err
+	}
+
+	purchaseOrder.ID = current.ID
+	purchaseOrder.Status = current.Status
+	purchaseOrder.CreatedAt = current.CreatedAt
+	return p.purchaseOrderRepo.UpdatePurchaseOrder(id, purchaseOrder)
+}
+
+// Delete a purchase order that has not been sent yet.
+func (p *purchaseOrderService) ServiceDeletePurchaseOrder(id string) error {
+	purchaseOrder, err := p.purchaseOrderRepo.GetPurchaseOrderID(id)
+	if err != nil {
+		return err
+	}
+	if purchaseOrder.Status != models.PurchaseOrderDraft {
+		slog.Error("Failed to delete purchase order", "error", myerrors.ErrPurchaseOrderState, "status", purchaseOrder.Status)
+		return myerrors.ErrPurchaseOrderState
+	}
+
+	return p.purchaseOrderRepo.DeletePurchaseOrder(id)
+}
+
+// Mark a draft purchase order as sent to the supplier.
+func (p *purchaseOrderService) ServiceSendPurchaseOrder(id string) error {
+	purchaseOrder, err := p.purchaseOrderRepo.GetPurchaseOrderID(id)
+	if err != nil {
+		return err
+	}
+	if purchaseOrder.Status != models.PurchaseOrderDraft {
+		slog.Error("Failed to send purchase order", "error", myerrors.ErrPurchaseOrderState, "status", purchaseOrder.Status)
+		return myerrors.ErrPurchaseOrderState
+	}
+
+	purchaseOrder.Status = models.PurchaseOrderSent
+	purchaseOrder.SentAt = time.Now().Format(time.RFC3339)
+	return p.purchaseOrderRepo.UpdatePurchaseOrder(id, purchaseOrder)
+}
+
+// Receive a delivery for a sent purchase order. The received packs are added to
+// the inventory as receipt movements carrying their cost, the order stays
+// partially received until every line has arrived.
+func (p *purchaseOrderService) ServiceReceivePurchaseOrder(id string, receiptByte []byte) ([]byte, error) {
+	purchaseOrder, err := p.purchaseOrderRepo.GetPurchaseOrderID(id)
+	if err != nil {
+		return nil, err
+	}
+	if purchaseOrder.Status != models.PurchaseOrderSent && purchaseOrder.Status != models.PurchaseOrderPartiallyReceived {
+		slog.Error("Failed to receive purchase order", "error", myerrors.ErrPurchaseOrderState, "status", purchaseOrder.Status)
+		return nil, myerrors.ErrPurchaseOrderState
+	}
+
+	var receipt models.PurchaseOrderReceipt
+	if len(receiptByte) > 0 {
+		if err := json.Unmarshal(receiptByte, &receipt); err != nil {
+			slog.Error("Failed to unmarshal", "error", err)
+			return nil, myerrors.ErrFailUnmarshal
+		}
+	}
+	if len(receipt.Lines) == 0 {
+		for _, line := range purchaseOrder.Lines {
+			if outstanding := line.Packs - line.ReceivedPacks; outstanding > 0 {
+				receipt.Lines = append(receipt.Lines, models.PurchaseOrderReceiptLine{IngredientID: line.IngredientID, Packs: outstanding})
+			}
+		}
+	}
+
+	var movements []models.InventoryMovement
+	var lots []models.InventoryLot
+	for _, received := range receipt.Lines {
+		if received.Packs <= 0 {
+			slog.Error("Failed to receive purchase order", "error", myerrors.ErrInvalidQuantity, "packs", received.Packs)
+			return nil, myerrors.ErrInvalidQuantity
+		}
+
+		i := purchaseOrderLine(purchaseOrder, received.IngredientID)
+		if i < 0 {
+			slog.Error("Failed to receive purchase order", "error", myerrors.ErrNotFound, "ingredient", received.IngredientID)
+			return nil, myerrors.ErrNotFound
+		}
+		line := &purchaseOrder.Lines[i]
+		if line.ReceivedPacks+received.Packs > line.Packs {
+			slog.Error("Failed to receive purchase order", "error", myerrors.ErrOverReceipt, "ingredient", received.IngredientID)
+			return nil, myerrors.ErrOverReceipt
+		}
+
+		line.ReceivedPacks += received.Packs
+		cost := received.Packs * line.PackPrice
+		purchaseOrder.ReceivedCost += cost
+		movement := models.InventoryMovement{
+			IngredientID: line.IngredientID,
+			Delta:        received.Packs * line.PackSize,
+			Reason:       models.MovementReceipt,
+			ReferenceID:  purchaseOrder.ID,
+			Cost:         cost,
+		}
+		if received.ExpiresAt != "" {
+			lot, err := newInventoryLot(line.IngredientID, movement.Delta, received.ExpiresAt, purchaseOrder.ID)
+			if err != nil {
+				return nil, err
+			}
+			movement.LotID = lot.ID
+			lots = append(lots, lot)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := p.ledger.Receive(lots, movements); err != nil {
+		return nil, err
+	}
+
+	purchaseOrder.Status = models.PurchaseOrderReceived
+	for _, line := range purchaseOrder.Lines {
+		if line.ReceivedPacks < line.Packs {
+			purchaseOrder.Status = models.PurchaseOrderPartiallyReceived
+		}
+	}
+	if purchaseOrder.Status == models.PurchaseOrderReceived {
+		purchaseOrder.ReceivedAt = time.Now().Format(time.RFC3339)
+	}
+
+	if err := p.purchaseOrderRepo.UpdatePurchaseOrder(id, purchaseOrder); err != nil {
+		return nil, err
+	}
+
+	return marshalPurchaseOrder(purchaseOrder)
+}
+
+// decodePurchaseOrder unmarshals and validates a purchase order and prices its lines from the supplier.
+func (p *purchaseOrderService) decodePurchaseOrder(data []byte) (models.PurchaseOrder, error) {
+	var purchaseOrder models.PurchaseOrder
+	if err := json.Unmarshal(data, &purchaseOrder); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.PurchaseOrder{}, myerrors.ErrFailUnmarshal
+	}
+
+	if err := validation.CheckPurchaseOrder(purchaseOrder); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	supplier, err := p.supplierRepo.GetSupplierID(purchaseOrder.SupplierID)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	seen := make(map[string]bool)
+	purchaseOrder.TotalCost = 0
+	for i, line := range purchaseOrder.Lines {
+		if seen[line.IngredientID] {
+			slog.Error("Failed to validate purchase order", "error", myerrors.ErrDuplicateID, "ingredient", line.IngredientID)
+			return models.PurchaseOrder{}, myerrors.ErrDuplicateID
+		}
+		seen[line.IngredientID] = true
+
+		item, ok := supplierItem(supplier, line.IngredientID)
+		if !ok {
+			slog.Error("Failed to validate purchase order", "error", myerrors.ErrNotSupplied, "ingredient", line.IngredientID)
+			return models.PurchaseOrder{}, myerrors.ErrNotSupplied
+		}
+
+		purchaseOrder.Lines[i].PackSize = item.PackSize
+		purchaseOrder.Lines[i].PackPrice = item.Price
+		purchaseOrder.Lines[i].ReceivedPacks = 0
+		purchaseOrder.TotalCost += line.Packs * item.Price
+	}
+	purchaseOrder.ReceivedCost = 0
+
+	return purchaseOrder, nil
+}
+
+func supplierItem(supplier models.Supplier, ingredientID string) (models.SupplierItem, bool) {
+	for _, item := range supplier.Items {
+		if item.IngredientID == ingredientID {
+			return item, true
+		}
+	}
+	return models.SupplierItem{}, false
+}
+
+func purchaseOrderLine(purchaseOrder models.PurchaseOrder, ingredientID string) int {
+	for i, line := range purchaseOrder.Lines {
+		if line.IngredientID == ingredientID {
+			return i
+		}
+	}
+	return -1
+}
+
+func marshalPurchaseOrder(v any) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
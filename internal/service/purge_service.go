@@ -0,0 +1,87 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type PurgeService interface {
+	ServicePurge(retention time.Duration) ([]byte, error)
+}
+
+type purgeService struct {
+	menuRepo      dal.MenuRepository
+	inventoryRepo dal.InventoryRepository
+	orderRepo     dal.OrderRepository
+}
+
+func NewPurgeService(menuRepo dal.MenuRepository, inventoryRepo dal.InventoryRepository, orderRepo dal.OrderRepository) PurgeService {
+	return &purgeService{
+		menuRepo:      menuRepo,
+		inventoryRepo: inventoryRepo,
+		orderRepo:     orderRepo,
+	}
+}
+
+// Remove for good the menu items, inventory items and orders archived longer than the
+// retention period. Recipe versions stay so reports on older orders keep working.
+func (p *purgeService) ServicePurge(retention time.Duration) ([]byte, error) {
+	before := time.Now().Add(-retention)
+	result := models.PurgeResult{ArchivedBefore: before.Format(time.RFC3339)}
+
+	menu, err := p.menuRepo.GetMenu()
+	if err != nil {
+		return nil, err
+	}
+	for _, menuItem := range menu {
+		if archivedBefore(menuItem.DeletedAt, before) {
+			if err := p.menuRepo.DeleteMenu(menuItem.ID); err != nil {
+				return nil, err
+			}
+			result.MenuItems++
+		}
+	}
+
+	inventory, err := p.inventoryRepo.GetInventory()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range inventory {
+		if archivedBefore(item.DeletedAt, before) {
+			if err := p.inventoryRepo.DeleteInventory(item.IngredientID); err != nil {
+				return nil, err
+			}
+			result.Inventory++
+		}
+	}
+
+	orders, err := p.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if archivedBefore(order.DeletedAt, before) {
+			if err := p.orderRepo.DeleteOrder(order.ID); err != nil {
+				return nil, err
+			}
+			result.Orders++
+		}
+	}
+
+	jsonFile, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func archivedBefore(deletedAt string, before time.Time) bool {
+	t, err := time.Parse(time.RFC3339, deletedAt)
+	return err == nil && t.Before(before)
+}
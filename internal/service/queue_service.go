@@ -0,0 +1,322 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type QueueService interface {
+	ServiceGetQueue(station string) ([]byte, error)
+	ServiceToggleLine(orderID string, line int, station string) ([]byte, error)
+	ServiceBumpOrder(orderID, station string) ([]byte, error)
+}
+
+type queueService struct {
+	orderRepo    dal.OrderRepository
+	menuRepo     dal.MenuRepository
+	categoryRepo dal.CategoryRepository
+	orders       OrderService
+	events       EventBus
+}
+
+func NewQueueService(orderRepo dal.OrderRepository, menuRepo dal.MenuRepository, categoryRepo dal.CategoryRepository, orders OrderService, events EventBus) QueueService {
+	return &queueService{
+		orderRepo:    orderRepo,
+		menuRepo:     menuRepo,
+		categoryRepo: categoryRepo,
+		orders:       orders,
+		events:       events,
+	}
+}
+
+// Retrieve the tickets every station still has to make, or only the given station's.
+// Open orders stay in the queue until they are ready, a station's ticket leaves it once
+// all of its lines are made. A bundle line shows up at every station making a part of it.
+func (q *queueService) ServiceGetQueue(station string) ([]byte, error) {
+	stations, err := q.stations()
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := q.orderRepo.GetOrder()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt < orders[j].CreatedAt
+	})
+
+	queue := []models.QueueStation{}
+	for _, name := range []string{models.StationBar, models.StationFood} {
+		if station != "" && station != name {
+			continue
+		}
+
+		tickets := []models.QueueTicket{}
+		for _, order := range orders {
+			if !inQueue(order) {
+				continue
+			}
+			ticket := models.QueueTicket{
+				OrderID:      order.ID,
+				CustomerName: order.CustomerName,
+				CreatedAt:    order.CreatedAt,
+				Lines:        []models.QueueLine{},
+			}
+			done := true
+			for i, item := range order.Items {
+				if !slices.Contains(stations.lineStations(item), name) {
+					continue
+				}
+				made := partMade(item, name)
+				ticket.Lines = append(ticket.Lines, models.QueueLine{
+					Line:       i,
+					ProductID:  item.ProductID,
+					Name:       stations.menu[item.ProductID].Name,
+					Quantity:   item.Quantity,
+					Choices:    item.Choices,
+					Components: stations.parts(item, name),
+					Made:       made,
+				})
+				done = done && made
+			}
+			if len(ticket.Lines) > 0 && !done {
+				tickets = append(tickets, ticket)
+			}
+		}
+		queue = append(queue, models.QueueStation{Station: name, Tickets: tickets})
+	}
+
+	jsonFile, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Tick a line of an order off as made, or back on when it was made. With a station only
+// the part of the line made there is ticked. The order is ready once all of its lines
+// are made.
+func (q *queueService) ServiceToggleLine(orderID string, line int, station string) ([]byte, error) {
+	return q.updateOrder(orderID, func(order *models.Order, stations stationMap) error {
+		if line < 0 || line >= len(order.Items) {
+			slog.Error("Failed to toggle line", "error", myerrors.ErrInvalidLine, "id", orderID, "line", line)
+			return myerrors.ErrInvalidLine
+		}
+
+		item := &order.Items[line]
+		lineStations := stations.lineStations(*item)
+		switch {
+		case station == "":
+			item.Made = !item.Made
+			item.MadeStations = nil
+		case slices.Contains(lineStations, station):
+			setMade(item, station, !partMade(*item, station), lineStations)
+		default:
+			slog.Error("Failed to toggle line", "error", myerrors.ErrInvalidLine, "id", orderID, "line", line, "station", station)
+			return myerrors.ErrInvalidLine
+		}
+		return nil
+	})
+}
+
+// Mark the lines of a station, or of every station, as made. The order is ready once
+// all of its lines are made and leaves the queue.
+func (q *queueService) ServiceBumpOrder(orderID, station string) ([]byte, error) {
+	return q.updateOrder(orderID, func(order *models.Order, stations stationMap) error {
+		for i, item := range order.Items {
+			lineStations := stations.lineStations(item)
+			switch {
+			case station == "":
+				order.Items[i].Made = true
+				order.Items[i].MadeStations = nil
+			case slices.Contains(lineStations, station):
+				setMade(&order.Items[i], station, true, lineStations)
+			}
+		}
+		return nil
+	})
+}
+
+// updateOrder reads an order still in the queue, applies change and saves it, all while
+// holding the orders lock. The order is ready once all of its lines are made.
+func (q *queueService) updateOrder(orderID string, change func(order *models.Order, stations stationMap) error) ([]byte, error) {
+	var order models.Order
+	err := q.orders.UpdateOrders(func() error {
+		var err error
+		order, err = q.orderRepo.GetOrderID(orderID)
+		if err != nil {
+			return err
+		}
+		if !inQueue(order) {
+			slog.Error("Failed to update queue", "error", myerrors.ErrNotInQueue, "id", orderID, "status", order.Status)
+			return myerrors.ErrNotInQueue
+		}
+
+		stations, err := q.stations()
+		if err != nil {
+			return err
+		}
+		if err := change(&order, stations); err != nil {
+			return err
+		}
+		if allMade(order) {
+			order.ReadyAt = time.Now().Format(time.RFC3339)
+		}
+		return q.orderRepo.UpdateOrder(order.ID, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if order.ReadyAt != "" {
+		q.events.Publish(models.TopicOrders, models.EventOrderReady, order.ID, order)
+	} else {
+		q.events.Publish(models.TopicOrders, models.EventOrderUpdated, order.ID, order)
+	}
+
+	jsonFile, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// stationMap tells at which station menu items are made.
+type stationMap struct {
+	menu       map[string]models.MenuItem
+	categories map[string]models.Category
+}
+
+func (q *queueService) stations() (stationMap, error) {
+	menuItems, err := q.menuRepo.GetMenu()
+	if err != nil {
+		return stationMap{}, err
+	}
+	categories, err := loadCategories(q.categoryRepo)
+	if err != nil {
+		return stationMap{}, err
+	}
+
+	menu := make(map[string]models.MenuItem)
+	for _, menuItem := range menuItems {
+		menu[menuItem.ID] = menuItem
+	}
+	return stationMap{menu: menu, categories: categories}, nil
+}
+
+// of returns the station of a menu item, then of its category, the bar by default.
+func (s stationMap) of(productID string) string {
+	menuItem := s.menu[productID]
+	if menuItem.Station != "" {
+		return menuItem.Station
+	}
+	if station := s.categories[menuItem.CategoryID].Station; station != "" {
+		return station
+	}
+	return models.StationBar
+}
+
+// lineStations returns the stations that make a part of an order line. A bundle is made
+// where its components and chosen options are made, and where it is made itself when
+// it has ingredients of its own.
+func (s stationMap) lineStations(item models.OrderItem) []string {
+	menuItem := s.menu[item.ProductID]
+	if len(menuItem.Components) == 0 && len(menuItem.Slots) == 0 {
+		return []string{s.of(item.ProductID)}
+	}
+
+	var stations []string
+	add := func(station string) {
+		if !slices.Contains(stations, station) {
+			stations = append(stations, station)
+		}
+	}
+	if len(menuItem.Ingredients) > 0 {
+		add(s.of(item.ProductID))
+	}
+	for _, component := range menuItem.Components {
+		add(s.of(component.ProductID))
+	}
+	for _, choice := range item.Choices {
+		add(s.of(choice.ProductID))
+	}
+	if len(stations) == 0 {
+		add(s.of(item.ProductID))
+	}
+	return stations
+}
+
+// parts lists what one serving of a bundle line needs from station: its components and
+// chosen options made there. Lines that are not bundles have no parts.
+func (s stationMap) parts(item models.OrderItem, station string) []models.BundleComponent {
+	menuItem := s.menu[item.ProductID]
+	var parts []models.BundleComponent
+	for _, component := range menuItem.Components {
+		if s.of(component.ProductID) == station {
+			parts = append(parts, component)
+		}
+	}
+	for _, slot := range menuItem.Slots {
+		for _, choice := range item.Choices {
+			if choice.Slot == slot.Name && s.of(choice.ProductID) == station {
+				parts = append(parts, models.BundleComponent{ProductID: choice.ProductID, Quantity: slot.Quantity})
+			}
+		}
+	}
+	return parts
+}
+
+// partMade tells whether station made its part of a line.
+func partMade(item models.OrderItem, station string) bool {
+	return item.Made || slices.Contains(item.MadeStations, station)
+}
+
+// setMade marks the part of a line made at station as made or not. The line is made
+// once every one of its stations made its part.
+func setMade(item *models.OrderItem, station string, made bool, stations []string) {
+	parts := item.MadeStations
+	if item.Made {
+		parts = slices.Clone(stations)
+	}
+	parts = slices.DeleteFunc(slices.Clone(parts), func(s string) bool {
+		return s == station
+	})
+	if made {
+		parts = append(parts, station)
+	}
+
+	item.Made = true
+	for _, s := range stations {
+		if !slices.Contains(parts, s) {
+			item.Made = false
+		}
+	}
+	item.MadeStations = nil
+	if !item.Made && len(parts) > 0 {
+		item.MadeStations = parts
+	}
+}
+
+// inQueue tells whether an order is still to be made.
+func inQueue(order models.Order) bool {
+	return order.Status == "open" && order.DeletedAt == "" && order.ReadyAt == ""
+}
+
+func allMade(order models.Order) bool {
+	for _, item := range order.Items {
+		if !item.Made {
+			return false
+		}
+	}
+	return true
+}
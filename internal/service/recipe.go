@@ -0,0 +1,330 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/units"
+	"hot-coffee/models"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// recipeBook expands menu items into the ingredient quantities they use, expressed
+// in the units the ingredients are kept in the inventory. Bundles expand through
+// the recipes of their components. Past recipe versions are kept so that orders
+// are made with the recipe they were placed with.
+type recipeBook struct {
+	menu      map[string]models.MenuItem
+	versions  map[string][]models.RecipeVersion
+	inventory []models.InventoryItem
+	units     []models.Unit
+}
+
+func loadRecipeBook(menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, inventoryRepo dal.InventoryRepository, unitRepo dal.UnitRepository) (recipeBook, error) {
+	menuItems, err := menuRepo.GetMenu()
+	if err != nil {
+		return recipeBook{}, err
+	}
+
+	recipeVersions, err := recipeRepo.GetRecipeVersions()
+	if err != nil {
+		return recipeBook{}, err
+	}
+
+	inventory, err := inventoryRepo.GetInventory()
+	if err != nil {
+		return recipeBook{}, err
+	}
+
+	customUnits, err := unitRepo.GetUnits()
+	if err != nil {
+		return recipeBook{}, err
+	}
+
+	menu := make(map[string]models.MenuItem)
+	for _, menuItem := range menuItems {
+		menu[menuItem.ID] = menuItem
+	}
+
+	versions := make(map[string][]models.RecipeVersion)
+	for _, version := range recipeVersions {
+		versions[version.ProductID] = append(versions[version.ProductID], version)
+	}
+	for productID := range versions {
+		sortRecipeVersions(versions[productID])
+	}
+
+	return recipeBook{menu: menu, versions: versions, inventory: inventory, units: customUnits}, nil
+}
+
+// recipe returns the menu item made with the given recipe version or, when version is
+// 0, with the version in effect at the given time. A zero time means the current recipe.
+// Menu items deleted since keep their recorded versions.
+func (b recipeBook) recipe(productID string, version int, at time.Time) (models.MenuItem, bool) {
+	menuItem, ok := b.menu[productID]
+	if version == 0 && at.IsZero() {
+		return menuItem, ok
+	}
+
+	var chosen *models.RecipeVersion
+	for i, recorded := range b.versions[productID] {
+		if version > 0 && recorded.Version == version {
+			chosen = &b.versions[productID][i]
+			break
+		}
+		if version == 0 && !recipeCreatedAfter(recorded, at) {
+			chosen = &b.versions[productID][i]
+		}
+	}
+	if chosen == nil {
+		return menuItem, ok
+	}
+
+	if !ok {
+		menuItem = models.MenuItem{ID: productID}
+	}
+	menuItem.Ingredients = chosen.Ingredients
+	menuItem.Components = chosen.Components
+	menuItem.Slots = chosen.Slots
+	menuItem.RecipeVersion = chosen.Version
+	return menuItem, true
+}
+
+// requirements returns how much of every ingredient the given number of servings use,
+// counting only the menu item's own ingredients.
+func (b recipeBook) requirements(menuItem models.MenuItem, quantity int) (map[string]float64, error) {
+	required := make(map[string]float64)
+	for _, ingredient := range menuItem.Ingredients {
+		qty, err := b.toInventoryUnit(ingredient)
+		if err != nil {
+			return nil, err
+		}
+		required[ingredient.IngredientID] += float64(quantity) * qty
+	}
+	return required, nil
+}
+
+// orderItemRequirements returns how much of every ingredient an order line uses,
+// bundles included. The line is made with its pinned recipe version and bundle
+// components with the versions in effect at the given time, see recipe.
+func (b recipeBook) orderItemRequirements(item models.OrderItem, at time.Time) (map[string]float64, error) {
+	menuItem, ok := b.recipe(item.ProductID, item.RecipeVersion, at)
+	if !ok {
+		return nil, myerrors.ErrNotFound
+	}
+
+	required, err := b.requirements(menuItem, item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := b.components(menuItem, item.Choices)
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		componentItem, _ := b.recipe(component.ProductID, 0, at)
+		componentRequired, err := b.requirements(componentItem, item.Quantity*component.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		for ingID, qty := range componentRequired {
+			required[ingID] += qty
+		}
+	}
+
+	return required, nil
+}
+
+// components lists the menu items one serving of a bundle is made of: its fixed
+// components followed by the chosen option of every slot. Items that are not
+// bundles have no components and take no choices.
+func (b recipeBook) components(menuItem models.MenuItem, choices []models.OrderItemChoice) ([]models.BundleComponent, error) {
+	if len(choices) != len(menuItem.Slots) {
+		slog.Error("Invalid bundle choices", "product", menuItem.ID, "slots", len(menuItem.Slots), "choices", len(choices))
+		return nil, myerrors.ErrInvalidChoice
+	}
+
+	components := make([]models.BundleComponent, 0, len(menuItem.Components)+len(menuItem.Slots))
+	for _, component := range menuItem.Components {
+		if !b.known(component.ProductID) {
+			slog.Error("Bundle component is not on the menu", "product", menuItem.ID, "component", component.ProductID)
+			return nil, myerrors.ErrInvalidComponent
+		}
+		components = append(components, component)
+	}
+
+	for _, slot := range menuItem.Slots {
+		chosen := ""
+		for _, choice := range choices {
+			if choice.Slot == slot.Name {
+				chosen = choice.ProductID
+			}
+		}
+		if !slices.Contains(slot.Options, chosen) {
+			slog.Error("Invalid bundle choice", "product", menuItem.ID, "slot", slot.Name, "choice", chosen)
+			return nil, myerrors.ErrInvalidChoice
+		}
+		if !b.known(chosen) {
+			slog.Error("Bundle option is not on the menu", "product", menuItem.ID, "option", chosen)
+			return nil, myerrors.ErrInvalidComponent
+		}
+		components = append(components, models.BundleComponent{ProductID: chosen, Quantity: slot.Quantity})
+	}
+
+	return components, nil
+}
+
+// known tells whether productID is on the menu or was before it was deleted.
+func (b recipeBook) known(productID string) bool {
+	_, ok := b.menu[productID]
+	return ok || len(b.versions[productID]) > 0
+}
+
+// checkIngredients verifies that every ingredient of the recipe is in the inventory
+// and not archived.
+func (b recipeBook) checkIngredients(menuItem models.MenuItem) error {
+	for _, ingredient := range menuItem.Ingredients {
+		if item, ok := findInventoryItem(ingredient.IngredientID, b.inventory); !ok || item.DeletedAt != "" {
+			slog.Error("Validation failed: unknown ingredient", "product", menuItem.ID, "ingredient", ingredient.IngredientID)
+			return myerrors.ErrUnknownIngredient
+		}
+	}
+	return nil
+}
+
+// checkUnits verifies that every recipe unit converts to the unit of its ingredient.
+// Ingredients missing from the inventory are not checked here, see checkIngredients.
+func (b recipeBook) checkUnits(menuItem models.MenuItem) error {
+	for _, ingredient := range menuItem.Ingredients {
+		if _, err := b.toInventoryUnit(ingredient); err != nil {
+			slog.Error("Validation failed: unit mismatch", "product", menuItem.ID, "ingredient", ingredient.IngredientID, "unit", ingredient.Unit)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkComponents verifies that the components and slot options of a bundle are menu
+// items that are neither bundles themselves nor archived, and that a bundle is not used
+// as a component.
+func (b recipeBook) checkComponents(menuItem models.MenuItem) error {
+	productIDs := make([]string, 0, len(menuItem.Components))
+	for _, component := range menuItem.Components {
+		productIDs = append(productIDs, component.ProductID)
+	}
+	for _, slot := range menuItem.Slots {
+		productIDs = append(productIDs, slot.Options...)
+	}
+
+	for _, productID := range productIDs {
+		component, ok := b.menu[productID]
+		if !ok || productID == menuItem.ID || isBundle(component) || component.DeletedAt != "" {
+			slog.Error("Validation failed: invalid bundle component", "product", menuItem.ID, "component", productID)
+			return myerrors.ErrInvalidComponent
+		}
+	}
+
+	if isBundle(menuItem) {
+		for _, other := range b.menu {
+			if other.ID != menuItem.ID && usesComponent(other, menuItem.ID) {
+				slog.Error("Validation failed: bundle used as a component", "product", menuItem.ID, "bundle", other.ID)
+				return myerrors.ErrInvalidComponent
+			}
+		}
+	}
+	return nil
+}
+
+func (b recipeBook) toInventoryUnit(ingredient models.MenuItemIngredient) (float64, error) {
+	item, ok := findInventoryItem(ingredient.IngredientID, b.inventory)
+	if !ok || ingredient.Unit == "" {
+		return ingredient.Quantity, nil
+	}
+
+	qty, err := units.Convert(ingredient.Quantity, ingredient.Unit, item.Unit, b.units)
+	if err != nil {
+		return 0, myerrors.ErrUnitMismatch
+	}
+	return qty, nil
+}
+
+// maxServings returns how many servings of menuItem the inventory can make. For a
+// bundle every slot is filled with the option that can be made most often.
+func (b recipeBook) maxServings(menuItem models.MenuItem) int {
+	var choices []models.OrderItemChoice
+	for _, slot := range menuItem.Slots {
+		best, bestServings := "", -1
+		for _, option := range slot.Options {
+			optionItem, ok := b.menu[option]
+			if !ok {
+				continue
+			}
+			if servings := b.maxServings(optionItem); servings > bestServings {
+				best, bestServings = option, servings
+			}
+		}
+		choices = append(choices, models.OrderItemChoice{Slot: slot.Name, ProductID: best})
+	}
+
+	required, err := b.orderItemRequirements(models.OrderItem{ProductID: menuItem.ID, Quantity: 1, Choices: choices}, time.Time{})
+	if err != nil {
+		return 0
+	}
+
+	servings := math.MaxInt
+	for ingID, qty := range required {
+		if qty <= 0 {
+			continue
+		}
+		item, ok := findInventoryItem(ingID, b.inventory)
+		if !ok || item.Quantity <= 0 {
+			return 0
+		}
+		servings = min(servings, int(math.Floor(item.Quantity/qty)))
+	}
+
+	if servings == math.MaxInt {
+		return 0
+	}
+	return servings
+}
+
+func isBundle(menuItem models.MenuItem) bool {
+	return len(menuItem.Components) > 0 || len(menuItem.Slots) > 0
+}
+
+// usesComponent tells whether a bundle includes productID as a component or slot option.
+func usesComponent(bundle models.MenuItem, productID string) bool {
+	for _, component := range bundle.Components {
+		if component.ProductID == productID {
+			return true
+		}
+	}
+	for _, slot := range bundle.Slots {
+		if slices.Contains(slot.Options, productID) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortRecipeVersions(versions []models.RecipeVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+}
+
+// recipeCreatedAfter tells whether a version was recorded after t. Versions without
+// a creation time predate version tracking.
+func recipeCreatedAfter(version models.RecipeVersion, t time.Time) bool {
+	createdAt, err := time.Parse(time.RFC3339, version.CreatedAt)
+	if err != nil {
+		return false
+	}
+	return createdAt.After(t)
+}
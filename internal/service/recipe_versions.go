@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// newRecipeVersions sets the recipe version of a menu item about to be saved and
+// returns the versions to record: the first one for a new item, the next one when the
+// recipe of current changed. Items created before versions were kept get their old
+// recipe recorded as version 1 first. A new item under the ID of a purged one goes on
+// from the versions recorded for it, so no version number is used twice.
+func newRecipeVersions(current *models.MenuItem, menuItem *models.MenuItem, book recipeBook, now time.Time) []models.RecipeVersion {
+	if current != nil && sameRecipe(*current, *menuItem) {
+		menuItem.RecipeVersion = current.RecipeVersion
+		return nil
+	}
+
+	var versions []models.RecipeVersion
+	last := 0
+	recorded := book.versions[menuItem.ID]
+	switch {
+	case len(recorded) > 0:
+		last = recorded[len(recorded)-1].Version
+	case current != nil:
+		last = 1
+		versions = append(versions, recipeVersion(*current, last, ""))
+	}
+
+	menuItem.RecipeVersion = last + 1
+	return append(versions, recipeVersion(*menuItem, last+1, now.Format(time.RFC3339)))
+}
+
+func recipeVersion(menuItem models.MenuItem, version int, createdAt string) models.RecipeVersion {
+	return models.RecipeVersion{
+		ID:          uuid.NewID("recipe"),
+		ProductID:   menuItem.ID,
+		Version:     version,
+		Ingredients: menuItem.Ingredients,
+		Components:  menuItem.Components,
+		Slots:       menuItem.Slots,
+		CreatedAt:   createdAt,
+	}
+}
+
+func saveRecipeVersions(recipeRepo dal.RecipeVersionRepository, versions []models.RecipeVersion) error {
+	for _, version := range versions {
+		if err := recipeRepo.CreateRecipeVersion(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameRecipe tells whether two menu items are made of the same things, an empty
+// list and a missing one are the same.
+func sameRecipe(a, b models.MenuItem) bool {
+	return sameList(a.Ingredients, b.Ingredients) && sameList(a.Components, b.Components) && sameList(a.Slots, b.Slots)
+}
+
+func sameList[T any](a, b []T) bool {
+	return (len(a) == 0 && len(b) == 0) || reflect.DeepEqual(a, b)
+}
+
+// recipeChanges lists what was added, removed or changed from previous to next, for
+// the first version everything counts as added.
+func recipeChanges(previous, next models.RecipeVersion) []models.RecipeChange {
+	changes := []models.RecipeChange{}
+
+	before := make(map[string]string)
+	after := make(map[string]string)
+	var order []string
+	add := func(kind, id, description string, into map[string]string) {
+		key := kind + "\x00" + id
+		if _, ok := before[key]; !ok {
+			if _, ok := after[key]; !ok {
+				order = append(order, key)
+			}
+		}
+		into[key] = description
+	}
+
+	for _, recipe := range []struct {
+		version models.RecipeVersion
+		into    map[string]string
+	}{{previous, before}, {next, after}} {
+		for _, ingredient := range recipe.version.Ingredients {
+			add("ingredient", ingredient.IngredientID, strings.TrimSpace(fmt.Sprintf("%g %s", ingredient.Quantity, ingredient.Unit)), recipe.into)
+		}
+		for _, component := range recipe.version.Components {
+			add("component", component.ProductID, fmt.Sprintf("%d", component.Quantity), recipe.into)
+		}
+		for _, slot := range recipe.version.Slots {
+			add("slot", slot.Name, fmt.Sprintf("%d of %s", slot.Quantity, strings.Join(slot.Options, ", ")), recipe.into)
+		}
+	}
+
+	for _, key := range order {
+		kind, id, _ := strings.Cut(key, "\x00")
+		b, wasThere := before[key]
+		a, isThere := after[key]
+		change := models.RecipeChange{Kind: kind, ID: id, Before: b, After: a}
+		switch {
+		case !wasThere:
+			change.Change = "added"
+		case !isThere:
+			change.Change = "removed"
+		case a != b:
+			change.Change = "changed"
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/schedule"
+	"hot-coffee/models"
+	"time"
+)
+
+func loadCategories(categoryRepo dal.CategoryRepository) (map[string]models.Category, error) {
+	categories, err := categoryRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.Category)
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	return byID, nil
+}
+
+// scheduledOpen tells whether a menu item and its category allow ordering it at t,
+// archived items and items of inactive categories cannot be ordered.
+func scheduledOpen(menuItem models.MenuItem, categories map[string]models.Category, t time.Time) bool {
+	if menuItem.DeletedAt != "" {
+		return false
+	}
+	if category, ok := categories[menuItem.CategoryID]; ok {
+		if !category.Active || !schedule.Open(category.Schedules, t) {
+			return false
+		}
+	}
+	return schedule.Open(menuItem.Schedules, t)
+}
+
+// menuItemOrderable is scheduledOpen for the menu: a bundle also needs all of its
+// fixed components and at least one option of every slot to be open.
+func menuItemOrderable(menuItem models.MenuItem, book recipeBook, categories map[string]models.Category, t time.Time) bool {
+	if !scheduledOpen(menuItem, categories, t) {
+		return false
+	}
+
+	for _, component := range menuItem.Components {
+		if !scheduledOpen(book.menu[component.ProductID], categories, t) {
+			return false
+		}
+	}
+	for _, slot := range menuItem.Slots {
+		open := false
+		for _, option := range slot.Options {
+			if option, ok := book.menu[option]; ok && scheduledOpen(option, categories, t) {
+				open = true
+				break
+			}
+		}
+		if !open {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/models"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// stockEpsilon is how far below zero a quantity may land through float rounding and
+// still count as zero.
+const stockEpsilon = 1e-9
+
+// StockLedger is the single place where inventory quantities change, every change
+// is kept as a movement so reports can tell how the stock got where it is. Its
+// methods run one at a time, so the inventory, lot and movement files are never
+// rewritten by two of them at once.
+type StockLedger interface {
+	Apply(movements []models.InventoryMovement) error
+	Record(write func() ([]models.InventoryMovement, error)) error
+	Receive(lots []models.InventoryLot, movements []models.InventoryMovement) error
+	ExpireLots(now time.Time) ([]models.InventoryLot, error)
+}
+
+type stockLedger struct {
+	inventoryRepo dal.InventoryRepository
+	movementRepo  dal.MovementRepository
+	lotRepo       dal.LotRepository
+	alerts        AlertService
+	events        EventBus
+
+	mu sync.Mutex
+}
+
+func NewStockLedger(inventoryRepo dal.InventoryRepository, movementRepo dal.MovementRepository, lotRepo dal.LotRepository, alerts AlertService, events EventBus) StockLedger {
+	return &stockLedger{
+		inventoryRepo: inventoryRepo,
+		movementRepo:  movementRepo,
+		lotRepo:       lotRepo,
+		alerts:        alerts,
+		events:        events,
+	}
+}
+
+// Apply changes the inventory by the delta of every movement and records the movements.
+func (l *stockLedger) Apply(movements []models.InventoryMovement) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.apply(movements)
+}
+
+// Record runs write, which stores its change to the inventory itself and returns the
+// movements it made. The movements are recorded, their removed stock is taken out of
+// the lots, they are published and the low-stock alerts of the ingredients involved
+// are re-evaluated.
+func (l *stockLedger) Record(write func() ([]models.InventoryMovement, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	movements, err := write()
+	if err != nil || len(movements) == 0 {
+		return err
+	}
+	return l.record(movements)
+}
+
+// Receive adds received stock to the inventory and then keeps the lots it came in.
+// A lot that fails to be stored leaves its stock untracked, never a lot without stock.
+func (l *stockLedger) Receive(lots []models.InventoryLot, movements []models.InventoryMovement) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.apply(movements); err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if err := l.lotRepo.CreateLot(lot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireLots writes off the remaining stock of every lot expired by now as waste and
+// returns the lots it expired.
+func (l *stockLedger) ExpireLots(now time.Time) ([]models.InventoryLot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lots, err := l.lotRepo.GetLots()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []models.InventoryLot
+	for _, lot := range lots {
+		if lot.Expired || lot.Quantity <= 0 || lotExpiry(lot).After(now) {
+			continue
+		}
+
+		inventory, err := l.inventoryRepo.GetInventory()
+		if err != nil {
+			return expired, err
+		}
+		var movements []models.InventoryMovement
+		if item, ok := findInventoryItem(lot.IngredientID, inventory); ok && item.Quantity > 0 {
+			movements = append(movements, models.InventoryMovement{
+				IngredientID: lot.IngredientID,
+				Delta:        -min(lot.Quantity, item.Quantity),
+				Reason:       models.MovementWaste,
+				ReferenceID:  lot.ID,
+				LotID:        lot.ID,
+				Note:         "lot expired",
+			})
+		}
+		if err := l.apply(movements); err != nil {
+			slog.Error("Failed to write off expired lot", "error", err, "lot", lot.ID)
+			continue
+		}
+
+		lot, err := l.lotRepo.GetLotID(lot.ID)
+		if err != nil {
+			return expired, err
+		}
+		lot.Quantity = 0
+		lot.Expired = true
+		if err := l.lotRepo.UpdateLot(lot.ID, lot); err != nil {
+			return expired, err
+		}
+		expired = append(expired, lot)
+	}
+
+	return expired, nil
+}
+
+func (l *stockLedger) apply(movements []models.InventoryMovement) error {
+	if err := changeInventory(l.inventoryRepo, movements); err != nil {
+		return err
+	}
+	return l.record(movements)
+}
+
+// changeInventory changes the inventory by the delta of every movement. Nothing is
+// written when an ingredient is unknown or a movement would take its stock below zero.
+func changeInventory(inventoryRepo dal.InventoryRepository, movements []models.InventoryMovement) error {
+	inventory, err := inventoryRepo.GetInventory()
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]models.InventoryItem)
+	var order []string
+	for _, movement := range movements {
+		item, ok := changed[movement.IngredientID]
+		if !ok {
+			item, ok = findInventoryItem(movement.IngredientID, inventory)
+			if !ok {
+				slog.Error("Failed to apply movement", "error", myerrors.ErrNotFound, "ingredient", movement.IngredientID)
+				return myerrors.ErrNotFound
+			}
+			order = append(order, movement.IngredientID)
+		}
+		item.Quantity += movement.Delta
+		// Quantities are floats, a sale of exactly the stock left may miss zero by a rounding error.
+		if movement.Delta < 0 && item.Quantity < -stockEpsilon {
+			slog.Error("Failed to apply movement", "error", myerrors.ErrNegativeStock, "ingredient", movement.IngredientID, "quantity", item.Quantity-movement.Delta, "delta", movement.Delta)
+			return myerrors.ErrNegativeStock
+		}
+		if movement.Delta < 0 {
+			item.Quantity = max(item.Quantity, 0)
+		}
+		changed[movement.IngredientID] = item
+	}
+
+	for _, id := range order {
+		if err := inventoryRepo.UpdateInventory(id, changed[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undoInventory reverts changeInventory of movements whose write did not complete.
+// It runs while the caller already fails, so its own failure is only logged.
+func undoInventory(inventoryRepo dal.InventoryRepository, movements []models.InventoryMovement) {
+	reverted := make([]models.InventoryMovement, len(movements))
+	for i, movement := range movements {
+		reverted[i] = movement
+		reverted[i].Delta = -movement.Delta
+	}
+	if err := changeInventory(inventoryRepo, reverted); err != nil {
+		slog.Error("Failed to revert stock change", "error", err)
+	}
+}
+
+func (l *stockLedger) record(movements []models.InventoryMovement) error {
+	now := time.Now().Format(time.RFC3339)
+
+	if err := l.consumeLots(movements); err != nil {
+		return err
+	}
+
+	var recorded []models.InventoryMovement
+	var ingredientIDs []string
+	for _, movement := range movements {
+		ingredientIDs = append(ingredientIDs, movement.IngredientID)
+		if movement.Delta == 0 {
+			continue
+		}
+		movement.ID = uuid.NewID("movement")
+		if movement.CreatedAt == "" {
+			movement.CreatedAt = now
+		}
+		recorded = append(recorded, movement)
+	}
+
+	if len(recorded) > 0 {
+		if err := l.movementRepo.CreateMovements(recorded); err != nil {
+			return err
+		}
+	}
+	for _, movement := range recorded {
+		l.events.Publish(models.TopicInventory, models.EventStockChanged, movement.IngredientID, movement)
+	}
+
+	l.alerts.CheckStock(ingredientIDs)
+	return nil
+}
+
+// consumeLots takes the stock removed by movements out of the lots: the lot a movement
+// names first, then first-expired-first-out. Stock beyond the lots is untracked.
+func (l *stockLedger) consumeLots(movements []models.InventoryMovement) error {
+	var lots []models.InventoryLot
+	loaded := false
+	changed := make(map[int]bool)
+
+	for _, movement := range movements {
+		if movement.Delta >= 0 {
+			continue
+		}
+		if !loaded {
+			var err error
+			if lots, err = l.lotRepo.GetLots(); err != nil {
+				return err
+			}
+			loaded = true
+		}
+
+		var candidates []int
+		for i, lot := range lots {
+			if lot.IngredientID == movement.IngredientID && lot.Quantity > 0 {
+				candidates = append(candidates, i)
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			lotA, lotB := lots[candidates[a]], lots[candidates[b]]
+			if (lotA.ID == movement.LotID) != (lotB.ID == movement.LotID) {
+				return lotA.ID == movement.LotID
+			}
+			return lotExpiry(lotA).Before(lotExpiry(lotB))
+		})
+
+		remaining := -movement.Delta
+		for _, i := range candidates {
+			if remaining <= 0 {
+				break
+			}
+			taken := min(remaining, lots[i].Quantity)
+			lots[i].Quantity -= taken
+			remaining -= taken
+			changed[i] = true
+		}
+	}
+
+	for i := range lots {
+		if !changed[i] {
+			continue
+		}
+		if err := l.lotRepo.UpdateLot(lots[i].ID, lots[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func lotExpiry(lot models.InventoryLot) time.Time {
+	t, err := time.Parse(time.RFC3339, lot.ExpiresAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func findInventoryItem(id string, inventory []models.InventoryItem) (models.InventoryItem, bool) {
+	for _, item := range inventory {
+		if item.IngredientID == id {
+			return item, true
+		}
+	}
+	return models.InventoryItem{}, false
+}
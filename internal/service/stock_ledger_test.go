@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"hot-coffee/internal/config"
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// useDataDir points the repositories at a new data directory holding files, every
+// other data file starts out as an empty list.
+func useDataDir(t *testing.T, files map[string]any) {
+	t.Helper()
+
+	dir := t.TempDir()
+	previous := config.Dir
+	config.Dir = &dir
+	t.Cleanup(func() { config.Dir = previous })
+
+	for _, name := range []string{"orders.json", "menu_items.json", "inventory_item.json", "inventory_movements.json", "stock_alerts.json", "units.json", "inventory_lots.json", "stocktakes.json", "recipe_versions.json", "categories.json", "menu_prices.json", "loyalty_rules.json", "loyalty_transactions.json"} {
+		if _, ok := files[name]; !ok {
+			files[name] = []any{}
+		}
+	}
+	for name, content := range files {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestLedger() StockLedger {
+	inventoryRepo := dal.NewInventoryRepository("inventory_item.json")
+	events := NewEventBus(10)
+	alerts := NewAlertService(dal.NewAlertRepository("stock_alerts.json"), inventoryRepo, "", events)
+	return NewStockLedger(inventoryRepo, dal.NewMovementRepository("inventory_movements.json"), dal.NewLotRepository("inventory_lots.json"), alerts, events)
+}
+
+func TestStockLedgerApplyRefusesNegativeStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		deltas    []float64
+		wantErr   error
+		wantStock float64
+	}{
+		{name: "takes stock", deltas: []float64{-40}, wantStock: 60},
+		{name: "takes all of the stock", deltas: []float64{-60, -40}, wantStock: 0},
+		{name: "takes all of the stock with rounding", deltas: []float64{-0.1, -0.2, -99.7}, wantStock: 0},
+		{name: "takes more than the stock", deltas: []float64{-101}, wantErr: myerrors.ErrNegativeStock, wantStock: 100},
+		{name: "takes more than the stock over several movements", deltas: []float64{-60, -60}, wantErr: myerrors.ErrNegativeStock, wantStock: 100},
+		{name: "adds stock", deltas: []float64{25}, wantStock: 125},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataDir(t, map[string]any{
+				"inventory_item.json": []models.InventoryItem{{IngredientID: "milk", Name: "Milk", Quantity: 100, Unit: "ml"}},
+			})
+
+			var movements []models.InventoryMovement
+			for _, delta := range tt.deltas {
+				movements = append(movements, models.InventoryMovement{IngredientID: "milk", Delta: delta, Reason: models.MovementAdjustment})
+			}
+			if err := newTestLedger().Apply(movements); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+
+			item, err := dal.NewInventoryRepository("inventory_item.json").GetInventoryID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Quantity != tt.wantStock {
+				t.Errorf("quantity = %v, want %v", item.Quantity, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestServicePostOrderCloseConcurrently(t *testing.T) {
+	tests := []struct {
+		name       string
+		orders     []string
+		closes     int
+		stock      float64
+		wantClosed int
+		wantStock  float64
+	}{
+		{name: "same order closed twice", orders: []string{"o1"}, closes: 2, stock: 1000, wantClosed: 1, wantStock: 800},
+		{name: "same order closed many times", orders: []string{"o1"}, closes: 20, stock: 1000, wantClosed: 1, wantStock: 800},
+		{name: "stock for one of two orders", orders: []string{"o1", "o2"}, closes: 1, stock: 300, wantClosed: 1, wantStock: 100},
+		{name: "stock for both orders", orders: []string{"o1", "o2"}, closes: 1, stock: 400, wantClosed: 2, wantStock: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createdAt := time.Now().Add(-time.Minute).Format(time.RFC3339)
+			var orders []models.Order
+			for _, id := range tt.orders {
+				orders = append(orders, models.Order{ID: id, CustomerName: "Alice", Items: []models.OrderItem{{ProductID: "latte", Quantity: 1}}, Status: "open", CreatedAt: createdAt})
+			}
+			useDataDir(t, map[string]any{
+				"orders.json":         orders,
+				"menu_items.json":     []models.MenuItem{{ID: "latte", Name: "Latte", Price: 3.5, Ingredients: []models.MenuItemIngredient{{IngredientID: "milk", Quantity: 200}}}},
+				"inventory_item.json": []models.InventoryItem{{IngredientID: "milk", Name: "Milk", Quantity: tt.stock, Unit: "ml"}},
+			})
+
+			orderRepo := dal.NewOrderRepository("orders.json")
+			inventoryRepo := dal.NewInventoryRepository("inventory_item.json")
+			movementRepo := dal.NewMovementRepository("inventory_movements.json")
+			s := NewOrderService(orderRepo, dal.NewMenuRepository("menu_items.json"), dal.NewRecipeVersionRepository("recipe_versions.json"), nil, inventoryRepo, dal.NewUnitRepository("units.json"), nil, nil, dal.NewLoyaltyRuleRepository("loyalty_rules.json"), dal.NewLoyaltyTransactionRepository("loyalty_transactions.json"), nil, nil, newTestLedger(), NewEventBus(10), time.UTC)
+
+			// The closes are held until all of them are started, so they overlap.
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for _, id := range tt.orders {
+				for range tt.closes {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						<-start
+						err := s.ServicePostOrderClose(id)
+						if err != nil && err != myerrors.ErrOrderClosed && err != myerrors.ErrNotEnoughIngridients {
+							t.Errorf("ServicePostOrderClose(%q) error = %v", id, err)
+						}
+					}()
+				}
+			}
+			close(start)
+			wg.Wait()
+
+			movements, err := movementRepo.GetMovements()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(movements) != tt.wantClosed {
+				t.Errorf("%d sale movements, want %d", len(movements), tt.wantClosed)
+			}
+
+			stored, err := orderRepo.GetOrder()
+			if err != nil {
+				t.Fatal(err)
+			}
+			closed := 0
+			for _, order := range stored {
+				if order.Status == "closed" {
+					closed++
+				}
+			}
+			if closed != tt.wantClosed {
+				t.Errorf("%d orders closed, want %d", closed, tt.wantClosed)
+			}
+
+			item, err := inventoryRepo.GetInventoryID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Quantity != tt.wantStock {
+				t.Errorf("quantity = %v, want %v", item.Quantity, tt.wantStock)
+			}
+		})
+	}
+}
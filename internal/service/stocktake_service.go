@@ -0,0 +1,312 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// stocktakeNote marks the adjustment movements made by committing a stocktake.
+const stocktakeNote = "stocktake"
+
+type StocktakeService interface {
+	ServiceGetStocktakes(status string) ([]byte, error)
+	ServiceGetStocktakeID(id string) ([]byte, error)
+	ServiceStartStocktake(newStocktake []byte) ([]byte, error)
+	ServiceSubmitCounts(id string, counts []byte) ([]byte, error)
+	ServiceCommitStocktake(id string) ([]byte, error)
+}
+
+type stocktakeService struct {
+	stocktakeRepo dal.StocktakeRepository
+	inventoryRepo dal.InventoryRepository
+	ledger        StockLedger
+}
+
+func NewStocktakeService(stocktakeRepo dal.StocktakeRepository, inventoryRepo dal.InventoryRepository, ledger StockLedger) StocktakeService {
+	return &stocktakeService{
+		stocktakeRepo: stocktakeRepo,
+		inventoryRepo: inventoryRepo,
+		ledger:        ledger,
+	}
+}
+
+// Retrieve stocktakes, newest first, optionally filtered by status.
+func (s *stocktakeService) ServiceGetStocktakes(status string) ([]byte, error) {
+	stocktakes, err := s.stocktakeRepo.GetStocktakes()
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.inventoryRepo.GetInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []models.Stocktake{}
+	for i := len(stocktakes) - 1; i >= 0; i-- {
+		if status == "" || stocktakes[i].Status == status {
+			filtered = append(filtered, refreshStocktake(stocktakes[i], inventory))
+		}
+	}
+
+	return marshalStocktake(filtered)
+}
+
+// Retrieve a stocktake with its variance against the system quantities.
+func (s *stocktakeService) ServiceGetStocktakeID(id string) ([]byte, error) {
+	stocktake, err := s.stocktakeRepo.GetStocktakeID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.inventoryRepo.GetInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalStocktake(refreshStocktake(stocktake, inventory))
+}
+
+// Start a count of the listed ingredients, or of the whole inventory when no lines are given.
+// Only one stocktake can be open at a time.
+func (s *stocktakeService) ServiceStartStocktake(newStocktake []byte) ([]byte, error) {
+	var stocktake models.Stocktake
+	if len(newStocktake) > 0 {
+		if err := json.Unmarshal(newStocktake, &stocktake); err != nil {
+			slog.Error("Failed to unmarshal", "error", err)
+			return nil, myerrors.ErrFailUnmarshal
+		}
+	}
+
+	stocktakes, err := s.stocktakeRepo.GetStocktakes()
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range stocktakes {
+		if existing.Status == models.StocktakeOpen {
+			slog.Error("Failed to start stocktake", "error", myerrors.ErrStocktakeOpen, "open", existing.ID)
+			return nil, myerrors.ErrStocktakeOpen
+		}
+	}
+
+	inventory, err := s.inventoryRepo.GetInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []models.StocktakeLine
+	if len(stocktake.Lines) == 0 {
+		for _, item := range inventory {
+			if item.DeletedAt == "" {
+				lines = append(lines, models.StocktakeLine{IngredientID: item.IngredientID})
+			}
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, line := range stocktake.Lines {
+			if _, ok := findInventoryItem(line.IngredientID, inventory); !ok {
+				slog.Error("Failed to start stocktake", "error", myerrors.ErrUnknownIngredient, "ingredient", line.IngredientID)
+				return nil, myerrors.ErrUnknownIngredient
+			}
+			if seen[line.IngredientID] {
+				slog.Error("Failed to start stocktake", "error", myerrors.ErrDuplicateID, "ingredient", line.IngredientID)
+				return nil, myerrors.ErrDuplicateID
+			}
+			seen[line.IngredientID] = true
+			lines = append(lines, models.StocktakeLine{IngredientID: line.IngredientID})
+		}
+	}
+
+	stocktake = models.Stocktake{
+		ID:        uuid.NewID("stocktake"),
+		Status:    models.StocktakeOpen,
+		Note:      stocktake.Note,
+		Lines:     lines,
+		StartedAt: time.Now().Format(time.RFC3339),
+	}
+	stocktake = refreshStocktake(stocktake, inventory)
+
+	if err := s.stocktakeRepo.CreateStocktake(stocktake); err != nil {
+		return nil, err
+	}
+
+	return marshalStocktake(stocktake)
+}
+
+// Record counted quantities on an open stocktake.
+func (s *stocktakeService) ServiceSubmitCounts(id string, countsByte []byte) ([]byte, error) {
+	var counts models.StocktakeCounts
+	if err := json.Unmarshal(countsByte, &counts); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return nil, myerrors.ErrFailUnmarshal
+	}
+
+	if err := validation.CheckStocktakeCounts(counts); err != nil {
+		return nil, err
+	}
+
+	// Counts are stored in a ledger step, so they can not overwrite a commit made meanwhile
+	// and the system quantity they keep is not half way through a stock change.
+	var stocktake models.Stocktake
+	err := s.ledger.Record(func() ([]models.InventoryMovement, error) {
+		var err error
+		stocktake, err = s.openStocktake(id)
+		if err != nil {
+			return nil, err
+		}
+
+		inventory, err := s.inventoryRepo.GetInventory()
+		if err != nil {
+			return nil, err
+		}
+
+		// The system quantity is taken when the shelf is counted, so stock that moves
+		// between the count and the commit is not mistaken for a variance.
+		for _, count := range counts.Counts {
+			i := stocktakeLine(stocktake, count.IngredientID)
+			if i < 0 {
+				slog.Error("Failed to submit counts", "error", myerrors.ErrNotFound, "ingredient", count.IngredientID)
+				return nil, myerrors.ErrNotFound
+			}
+			if item, ok := findInventoryItem(count.IngredientID, inventory); ok {
+				stocktake.Lines[i].SystemQuantity = item.Quantity
+			}
+			stocktake.Lines[i].Counted = true
+			stocktake.Lines[i].CountedQuantity = count.CountedQuantity
+		}
+		stocktake = refreshStocktake(stocktake, inventory)
+
+		return nil, s.stocktakeRepo.UpdateStocktake(id, stocktake)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalStocktake(stocktake)
+}
+
+// Commit an open stocktake. Every counted line that differs from the system quantity
+// at the time of its count becomes an adjustment movement by that variance, so sales
+// since the count are kept. A variance larger than the stock left only takes the stock
+// to zero. Lines that were not counted are left alone. A committed stocktake is locked
+// and keeps the quantities it was committed with.
+func (s *stocktakeService) ServiceCommitStocktake(id string) ([]byte, error) {
+	// The status is checked, the stock corrected and the stocktake marked committed in
+	// one ledger step, so committing twice at once corrects the stock only once.
+	var stocktake models.Stocktake
+	err := s.ledger.Record(func() ([]models.InventoryMovement, error) {
+		var err error
+		stocktake, err = s.openStocktake(id)
+		if err != nil {
+			return nil, err
+		}
+
+		inventory, err := s.inventoryRepo.GetInventory()
+		if err != nil {
+			return nil, err
+		}
+		stocktake = refreshStocktake(stocktake, inventory)
+
+		var movements []models.InventoryMovement
+		for _, line := range stocktake.Lines {
+			if !line.Counted || line.Variance == 0 {
+				continue
+			}
+			delta := line.Variance
+			if item, ok := findInventoryItem(line.IngredientID, inventory); ok {
+				delta = max(delta, -item.Quantity)
+			}
+			if delta == 0 {
+				continue
+			}
+			movements = append(movements, models.InventoryMovement{
+				IngredientID: line.IngredientID,
+				Delta:        delta,
+				Reason:       models.MovementAdjustment,
+				ReferenceID:  stocktake.ID,
+				Note:         stocktakeNote,
+			})
+		}
+
+		if err := changeInventory(s.inventoryRepo, movements); err != nil {
+			return nil, err
+		}
+
+		stocktake.Status = models.StocktakeCommitted
+		stocktake.CommittedAt = time.Now().Format(time.RFC3339)
+		if err := s.stocktakeRepo.UpdateStocktake(id, stocktake); err != nil {
+			undoInventory(s.inventoryRepo, movements)
+			return nil, err
+		}
+		return movements, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalStocktake(stocktake)
+}
+
+func (s *stocktakeService) openStocktake(id string) (models.Stocktake, error) {
+	stocktake, err := s.stocktakeRepo.GetStocktakeID(id)
+	if err != nil {
+		return models.Stocktake{}, err
+	}
+	if stocktake.Status != models.StocktakeOpen {
+		slog.Error("Failed to change stocktake", "error", myerrors.ErrStocktakeCommitted, "id", id)
+		return models.Stocktake{}, myerrors.ErrStocktakeCommitted
+	}
+	return stocktake, nil
+}
+
+// refreshStocktake copies the current system quantities into the lines of an open
+// stocktake that were not counted yet and computes the variance of counted lines,
+// committed stocktakes are returned as stored.
+func refreshStocktake(stocktake models.Stocktake, inventory []models.InventoryItem) models.Stocktake {
+	if stocktake.Status != models.StocktakeOpen {
+		return stocktake
+	}
+
+	lines := make([]models.StocktakeLine, len(stocktake.Lines))
+	for i, line := range stocktake.Lines {
+		if item, ok := findInventoryItem(line.IngredientID, inventory); ok {
+			line.Unit = item.Unit
+			if !line.Counted {
+				line.SystemQuantity = item.Quantity
+			}
+		}
+		line.Variance = 0
+		if line.Counted {
+			line.Variance = line.CountedQuantity - line.SystemQuantity
+		}
+		lines[i] = line
+	}
+	stocktake.Lines = lines
+
+	return stocktake
+}
+
+func stocktakeLine(stocktake models.Stocktake, ingredientID string) int {
+	for i, line := range stocktake.Lines {
+		if line.IngredientID == ingredientID {
+			return i
+		}
+	}
+	return -1
+}
+
+func marshalStocktake(v any) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
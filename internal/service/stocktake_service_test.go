@@ -0,0 +1,104 @@
+package service
+
+import (
+	"hot-coffee/internal/dal"
+	"hot-coffee/models"
+	"sync"
+	"testing"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+func TestServiceCommitStocktake(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		line          models.StocktakeLine
+		stock         float64
+		commits       int
+		wantErr       error
+		wantStock     float64
+		wantMovements int
+	}{
+		{name: "counted less", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 90}, stock: 100, commits: 1, wantStock: 90, wantMovements: 1},
+		{name: "counted more", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 120}, stock: 100, commits: 1, wantStock: 120, wantMovements: 1},
+		{name: "counted as expected", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 100}, stock: 100, commits: 1, wantStock: 100},
+		{name: "sales since the count are kept", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 90}, stock: 70, commits: 1, wantStock: 60, wantMovements: 1},
+		{name: "variance larger than the stock left", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 10}, stock: 50, commits: 1, wantStock: 0, wantMovements: 1},
+		{name: "line not counted", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100}, stock: 80, commits: 1, wantStock: 80},
+		{name: "committed concurrently", status: models.StocktakeOpen, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 90}, stock: 100, commits: 10, wantStock: 90, wantMovements: 1},
+		{name: "already committed", status: models.StocktakeCommitted, line: models.StocktakeLine{SystemQuantity: 100, Counted: true, CountedQuantity: 90}, stock: 100, commits: 1, wantErr: myerrors.ErrStocktakeCommitted, wantStock: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.line
+			line.IngredientID = "milk"
+			useDataDir(t, map[string]any{
+				"stocktakes.json":     []models.Stocktake{{ID: "st1", Status: tt.status, Lines: []models.StocktakeLine{line}}},
+				"inventory_item.json": []models.InventoryItem{{IngredientID: "milk", Name: "Milk", Quantity: tt.stock, Unit: "ml"}},
+			})
+
+			stocktakeRepo := dal.NewStocktakeRepository("stocktakes.json")
+			inventoryRepo := dal.NewInventoryRepository("inventory_item.json")
+			s := NewStocktakeService(stocktakeRepo, inventoryRepo, newTestLedger())
+
+			start := make(chan struct{})
+			errs := make(chan error, tt.commits)
+			var wg sync.WaitGroup
+			for range tt.commits {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, err := s.ServiceCommitStocktake("st1")
+					errs <- err
+				}()
+			}
+			close(start)
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				switch err {
+				case nil:
+					succeeded++
+				case tt.wantErr, myerrors.ErrStocktakeCommitted:
+				default:
+					t.Errorf("ServiceCommitStocktake() error = %v", err)
+				}
+			}
+			if tt.wantErr == nil && succeeded != 1 {
+				t.Errorf("%d commits succeeded, want 1", succeeded)
+			}
+			if tt.wantErr != nil && succeeded != 0 {
+				t.Errorf("%d commits succeeded, want none", succeeded)
+			}
+
+			item, err := inventoryRepo.GetInventoryID("milk")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Quantity != tt.wantStock {
+				t.Errorf("quantity = %v, want %v", item.Quantity, tt.wantStock)
+			}
+
+			movements, err := dal.NewMovementRepository("inventory_movements.json").GetMovements()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(movements) != tt.wantMovements {
+				t.Errorf("%d movements, want %d", len(movements), tt.wantMovements)
+			}
+
+			stocktake, err := stocktakeRepo.GetStocktakeID("st1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stocktake.Status != models.StocktakeCommitted {
+				t.Errorf("status = %q, want %q", stocktake.Status, models.StocktakeCommitted)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type SupplierService interface {
+	ServiceGetSuppliers() ([]byte, error)
+	ServiceGetSupplierID(id string) ([]byte, error)
+	ServiceCreateSupplier(newSupplier []byte) error
+	ServiceUpdateSupplier(id string, newSupplier []byte) error
+	ServiceDeleteSupplier(id string) error
+}
+
+type supplierService struct {
+	supplierRepo  dal.SupplierRepository
+	inventoryRepo dal.InventoryRepository
+}
+
+func NewSupplierService(supplierRepo dal.SupplierRepository, inventoryRepo dal.InventoryRepository) SupplierService {
+	return &supplierService{
+		supplierRepo:  supplierRepo,
+		inventoryRepo: inventoryRepo,
+	}
+}
+
+func (s *supplierService) ServiceGetSuppliers() ([]byte, error) {
+	suppliers, err := s.supplierRepo.GetSuppliers()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(suppliers, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (s *supplierService) ServiceGetSupplierID(id string) ([]byte, error) {
+	supplier, err := s.supplierRepo.GetSupplierID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(supplier, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+func (s *supplierService) ServiceCreateSupplier(newSupplier []byte) error {
+	supplier, err := s.decodeSupplier(newSupplier)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.supplierRepo.GetSupplierID(supplier.ID); err == nil {
+		slog.Error("Failed to create supplier", "error", myerrors.ErrIDExist)
+		return myerrors.ErrIDExist
+	}
+
+	return s.supplierRepo.CreateSupplier(supplier)
+}
+
+func (s *supplierService) ServiceUpdateSupplier(id string, newSupplier []byte) error {
+	supplier, err := s.decodeSupplier(newSupplier)
+	if err != nil {
+		return err
+	}
+
+	return s.supplierRepo.UpdateSupplier(id, supplier)
+}
+
+func (s *supplierService) ServiceDeleteSupplier(id string) error {
+	return s.supplierRepo.DeleteSupplier(id)
+}
+
+// decodeSupplier unmarshals and validates a supplier, every carried ingredient must be in the inventory.
+func (s *supplierService) decodeSupplier(data []byte) (models.Supplier, error) {
+	var supplier models.Supplier
+	if err := json.Unmarshal(data, &supplier); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.Supplier{}, myerrors.ErrFailUnmarshal
+	}
+
+	if err := validation.CheckSupplier(supplier); err != nil {
+		return models.Supplier{}, err
+	}
+
+	inventory, err := s.inventoryRepo.GetInventory()
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	for _, item := range supplier.Items {
+		if _, ok := findInventoryItem(item.IngredientID, inventory); !ok {
+			slog.Error("Failed to validate supplier", "error", myerrors.ErrUnknownIngredient, "ingredient", item.IngredientID)
+			return models.Supplier{}, myerrors.ErrUnknownIngredient
+		}
+	}
+
+	return supplier, nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/units"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/models"
+	"log/slog"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type UnitService interface {
+	ServiceGetUnits() ([]byte, error)
+	ServiceCreateUnit(newUnit []byte) error
+	ServiceDeleteUnit(name string) error
+}
+
+type unitService struct {
+	unitRepo      dal.UnitRepository
+	menuRepo      dal.MenuRepository
+	recipeRepo    dal.RecipeVersionRepository
+	orderRepo     dal.OrderRepository
+	inventoryRepo dal.InventoryRepository
+}
+
+func NewUnitService(unitRepo dal.UnitRepository, menuRepo dal.MenuRepository, recipeRepo dal.RecipeVersionRepository, orderRepo dal.OrderRepository, inventoryRepo dal.InventoryRepository) UnitService {
+	return &unitService{
+		unitRepo:      unitRepo,
+		menuRepo:      menuRepo,
+		recipeRepo:    recipeRepo,
+		orderRepo:     orderRepo,
+		inventoryRepo: inventoryRepo,
+	}
+}
+
+// Retrieve the predefined units followed by the custom ones.
+func (u *unitService) ServiceGetUnits() ([]byte, error) {
+	customUnits, err := u.unitRepo.GetUnits()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := json.MarshalIndent(append(units.Builtin(), customUnits...), "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
+
+// Add a custom unit such as a pack size.
+func (u *unitService) ServiceCreateUnit(newUnit []byte) error {
+	var unit models.Unit
+	if err := json.Unmarshal(newUnit, &unit); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return myerrors.ErrFailUnmarshal
+	}
+	unit.Name = units.Normalize(unit.Name)
+	unit.Base = units.Normalize(unit.Base)
+
+	if err := validation.CheckUnit(unit); err != nil {
+		return err
+	}
+
+	if units.IsBuiltin(unit.Name) {
+		slog.Error("Failed to create unit", "error", myerrors.ErrBuiltinUnit, "unit", unit.Name)
+		return myerrors.ErrBuiltinUnit
+	}
+
+	if _, err := u.unitRepo.GetUnitID(unit.Name); err == nil {
+		slog.Error("Failed to create unit", "error", myerrors.ErrIDExist, "unit", unit.Name)
+		return myerrors.ErrIDExist
+	}
+
+	customUnits, err := u.unitRepo.GetUnits()
+	if err != nil {
+		return err
+	}
+	if units.Cyclic(unit, customUnits) {
+		slog.Error("Failed to create unit", "error", myerrors.ErrUnitCycle, "unit", unit.Name, "base", unit.Base)
+		return myerrors.ErrUnitCycle
+	}
+
+	return u.unitRepo.CreateUnit(unit)
+}
+
+// Delete a custom unit that no inventory item, recipe or other custom unit uses.
+func (u *unitService) ServiceDeleteUnit(name string) error {
+	name = units.Normalize(name)
+	if units.IsBuiltin(name) {
+		slog.Error("Failed to delete unit", "error", myerrors.ErrBuiltinUnit, "unit", name)
+		return myerrors.ErrBuiltinUnit
+	}
+
+	customUnits, err := u.unitRepo.GetUnits()
+	if err != nil {
+		return err
+	}
+	for _, unit := range customUnits {
+		if units.Normalize(unit.Base) == name && units.Normalize(unit.Name) != name {
+			slog.Error("Failed to delete unit", "error", myerrors.ErrUnitInUse, "unit", unit.Name)
+			return myerrors.ErrUnitInUse
+		}
+	}
+
+	inventory, err := u.inventoryRepo.GetInventory()
+	if err != nil {
+		return err
+	}
+	for _, item := range inventory {
+		if units.Normalize(item.Unit) == name {
+			slog.Error("Failed to delete unit", "error", myerrors.ErrUnitInUse, "ingredient", item.IngredientID)
+			return myerrors.ErrUnitInUse
+		}
+	}
+
+	menu, err := u.menuRepo.GetMenu()
+	if err != nil {
+		return err
+	}
+	for _, menuItem := range menu {
+		if usesUnit(menuItem, name) {
+			slog.Error("Failed to delete unit", "error", myerrors.ErrUnitInUse, "product", menuItem.ID)
+			return myerrors.ErrUnitInUse
+		}
+	}
+
+	// Open orders are made with the recipes they were placed with, an older version
+	// may still use the unit after the current recipe stopped.
+	book, err := loadRecipeBook(u.menuRepo, u.recipeRepo, u.inventoryRepo, u.unitRepo)
+	if err != nil {
+		return err
+	}
+	orders, err := u.orderRepo.GetOrder()
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if order.Status != "open" || order.DeletedAt != "" {
+			continue
+		}
+		createdAt, _ := time.Parse(time.RFC3339, order.CreatedAt)
+		for _, item := range order.Items {
+			menuItem, ok := book.recipe(item.ProductID, item.RecipeVersion, createdAt)
+			if !ok {
+				continue
+			}
+			recipes := []models.MenuItem{menuItem}
+			components, _ := book.components(menuItem, item.Choices)
+			for _, component := range components {
+				if componentItem, ok := book.recipe(component.ProductID, 0, createdAt); ok {
+					recipes = append(recipes, componentItem)
+				}
+			}
+			for _, recipe := range recipes {
+				if usesUnit(recipe, name) {
+					slog.Error("Failed to delete unit", "error", myerrors.ErrUnitInUse, "order", order.ID, "product", recipe.ID)
+					return myerrors.ErrUnitInUse
+				}
+			}
+		}
+	}
+
+	return u.unitRepo.DeleteUnit(name)
+}
+
+// usesUnit tells whether an ingredient of the recipe is measured in the unit.
+func usesUnit(menuItem models.MenuItem, name string) bool {
+	for _, ingredient := range menuItem.Ingredients {
+		if units.Normalize(ingredient.Unit) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/webhook"
+	"hot-coffee/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The wait between two attempts doubles up to this.
+const maxWebhookBackoff = 24 * time.Hour
+
+func (w *webhookService) Start() {
+	w.events.Listen(w.queue.push)
+
+	w.mu.Lock()
+	deliveries, err := w.deliveryRepo.GetWebhookDeliveries()
+	w.mu.Unlock()
+	if err != nil {
+		slog.Error("Failed to resume webhook deliveries", "error", err)
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status != models.DeliveryPending {
+			continue
+		}
+		wait := time.Duration(0)
+		if next, err := time.Parse(time.RFC3339, delivery.NextAttemptAt); err == nil {
+			wait = time.Until(next)
+		}
+		w.schedule(delivery.ID, wait)
+	}
+
+	go func() {
+		for {
+			for _, event := range w.queue.pop() {
+				w.dispatch(event)
+			}
+		}
+	}()
+}
+
+// eventQueue holds the published events until the webhooks are dispatched. It has no
+// bound, so publishing never waits for the delivery log and no event is lost.
+type eventQueue struct {
+	mu     sync.Mutex
+	events []models.Event
+	ready  chan struct{}
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(event models.Event) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop waits for events and takes all of them.
+func (q *eventQueue) pop() []models.Event {
+	for {
+		q.mu.Lock()
+		events := q.events
+		q.events = nil
+		q.mu.Unlock()
+		if len(events) > 0 {
+			return events
+		}
+		<-q.ready
+	}
+}
+
+// dispatch logs a delivery of the event for every active webhook subscribed to it
+// and makes the first attempts.
+func (w *webhookService) dispatch(event models.Event) {
+	hooks, err := w.webhookRepo.GetWebhooks()
+	if err != nil {
+		slog.Error("Failed to dispatch event to webhooks", "error", err, "id", event.ID)
+		return
+	}
+
+	for _, hook := range hooks {
+		if !hook.Active || !subscribed(hook, event) {
+			continue
+		}
+		delivery, err := w.createDelivery(hook, event)
+		if err != nil {
+			continue
+		}
+		w.schedule(delivery.ID, 0)
+	}
+}
+
+// subscribed tells whether a webhook wants the event, by its type or its topic.
+func subscribed(hook models.Webhook, event models.Event) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, event.Type) || slices.Contains(hook.Events, event.Topic)
+}
+
+func (w *webhookService) createDelivery(hook models.Webhook, event models.Event) (models.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return models.WebhookDelivery{}, err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	delivery := models.WebhookDelivery{
+		ID:            uuid.NewID("delivery"),
+		WebhookID:     hook.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		Attempts:      []models.WebhookAttempt{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.deliveryRepo.CreateWebhookDelivery(delivery); err != nil {
+		slog.Error("Failed to log webhook delivery", "error", err, "webhook", hook.ID, "event", event.ID)
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (w *webhookService) schedule(id string, wait time.Duration) {
+	time.AfterFunc(wait, func() {
+		if _, err := w.deliver(id, false); err != nil {
+			slog.Error("Failed to deliver webhook", "error", err, "delivery", id)
+		}
+	})
+}
+
+// deliver makes an attempt at a pending delivery and logs it. A failed attempt is
+// retried after a wait that doubles every time, until the attempts run out and the
+// delivery is dead. A last attempt is never retried.
+func (w *webhookService) deliver(id string, last bool) (models.WebhookDelivery, error) {
+	w.mu.Lock()
+	delivery, err := w.deliveryRepo.GetWebhookDeliveryID(id)
+	w.mu.Unlock()
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if delivery.Status != models.DeliveryPending {
+		return delivery, nil
+	}
+
+	start := time.Now()
+	attempt := models.WebhookAttempt{At: start.Format(time.RFC3339)}
+	delivered := false
+
+	hook, err := w.webhookRepo.GetWebhookID(delivery.WebhookID)
+	if err != nil {
+		attempt.Error = "webhook is gone: " + err.Error()
+		last = true
+	} else {
+		target := hook.URL
+		if hook.TestMode {
+			target = w.testURL()
+		}
+		status, response, err := webhook.Send(webhook.Message{
+			URL:        target,
+			Secret:     hook.Secret,
+			WebhookID:  hook.ID,
+			DeliveryID: delivery.ID,
+			Event:      delivery.EventType,
+			Body:       delivery.Payload,
+		})
+		attempt.StatusCode = status
+		attempt.Response = response
+		attempt.DurationMS = time.Since(start).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		delivered = err == nil && status >= 200 && status < 300
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	var retry time.Duration
+	switch {
+	case delivered:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = time.Now().Format(time.RFC3339)
+		delivery.NextAttemptAt = ""
+	case last || len(delivery.Attempts) >= w.attempts:
+		delivery.Status = models.DeliveryDead
+		delivery.NextAttemptAt = ""
+		slog.Warn("Webhook delivery is dead", "delivery", delivery.ID, "webhook", delivery.WebhookID, "attempts", len(delivery.Attempts), "error", attempt.Error, "status", attempt.StatusCode)
+	default:
+		retry = w.backoff
+		for i := 1; i < len(delivery.Attempts) && retry < maxWebhookBackoff; i++ {
+			retry *= 2
+		}
+		retry = min(retry, maxWebhookBackoff)
+		delivery.NextAttemptAt = time.Now().Add(retry).Format(time.RFC3339)
+	}
+
+	w.mu.Lock()
+	err = w.deliveryRepo.UpdateWebhookDelivery(delivery.ID, delivery)
+	w.mu.Unlock()
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	if delivery.Status == models.DeliveryPending {
+		w.schedule(delivery.ID, retry)
+	}
+	return delivery, nil
+}
+
+// testURL starts the local test server on first use. It plays the receiver of the
+// webhooks in test mode: it checks the signature and echoes what it got.
+func (w *webhookService) testURL() string {
+	w.testOnce.Do(func() {
+		w.testServer = httptest.NewServer(http.HandlerFunc(w.receiveTest))
+		slog.Info("Webhook test server started", "url", w.testServer.URL)
+	})
+	return w.testServer.URL
+}
+
+func (w *webhookService) receiveTest(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	valid := false
+	if hook, err := w.webhookRepo.GetWebhookID(r.Header.Get(webhook.HeaderID)); err == nil {
+		valid = webhook.Verify(hook.Secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body)
+	}
+	slog.Info("Test webhook received", "webhook", r.Header.Get(webhook.HeaderID), "delivery", r.Header.Get(webhook.HeaderDelivery), "event", r.Header.Get(webhook.HeaderEvent), "signature_valid", valid)
+
+	rw.Header().Set("Content-Type", "application/json")
+	if !valid {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}
+	json.NewEncoder(rw).Encode(map[string]any{
+		"received":        r.Header.Get(webhook.HeaderEvent),
+		"delivery":        r.Header.Get(webhook.HeaderDelivery),
+		"signature_valid": valid,
+	})
+}
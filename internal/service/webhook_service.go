@@ -0,0 +1,250 @@
+package service
+
+import (
+	"encoding/json"
+	"hot-coffee/internal/dal"
+	"hot-coffee/internal/utils/uuid"
+	"hot-coffee/internal/utils/validation"
+	"hot-coffee/internal/utils/webhook"
+	"hot-coffee/models"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+type WebhookService interface {
+	ServiceGetWebhooks() ([]byte, error)
+	ServiceGetWebhookID(id string) ([]byte, error)
+	ServiceCreateWebhook(newWebhook []byte) ([]byte, error)
+	ServiceUpdateWebhook(id string, newWebhook []byte) error
+	ServiceDeleteWebhook(id string) error
+	ServiceGetDeliveries(id, status string) ([]byte, error)
+	ServiceTestWebhook(id string) ([]byte, error)
+	ServiceGetDeadLetters() ([]byte, error)
+	ServiceRetryDelivery(id string) ([]byte, error)
+	// Start subscribes the webhooks to the published events and picks up the
+	// deliveries still pending from before a restart.
+	Start()
+}
+
+type webhookService struct {
+	webhookRepo  dal.WebhookRepository
+	deliveryRepo dal.WebhookDeliveryRepository
+	events       EventBus
+	queue        *eventQueue
+	attempts     int
+	backoff      time.Duration
+
+	// mu guards the delivery log, attempts run in their own goroutines.
+	mu         sync.Mutex
+	testOnce   sync.Once
+	testServer *httptest.Server
+}
+
+func NewWebhookService(webhookRepo dal.WebhookRepository, deliveryRepo dal.WebhookDeliveryRepository, events EventBus, attempts int, backoff time.Duration) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		events:       events,
+		queue:        newEventQueue(),
+		attempts:     attempts,
+		backoff:      backoff,
+	}
+}
+
+// Retrieve all webhooks, without their secrets.
+func (w *webhookService) ServiceGetWebhooks() ([]byte, error) {
+	webhooks, err := w.webhookRepo.GetWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return marshalWebhook(webhooks)
+}
+
+// Retrieve a specific webhook, without its secret.
+func (w *webhookService) ServiceGetWebhookID(id string) ([]byte, error) {
+	hook, err := w.webhookRepo.GetWebhookID(id)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return marshalWebhook(hook)
+}
+
+// Create a webhook, the ID and, unless one is given, the signing secret are generated.
+func (w *webhookService) ServiceCreateWebhook(newWebhook []byte) ([]byte, error) {
+	hook, err := decodeWebhook(newWebhook)
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.Secret == "" {
+		if hook.Secret, err = webhook.NewSecret(); err != nil {
+			slog.Error("Failed to generate webhook secret", "error", err)
+			return nil, err
+		}
+	}
+	hook.ID = uuid.NewID("webhook")
+	hook.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := w.webhookRepo.CreateWebhook(hook); err != nil {
+		return nil, err
+	}
+	return marshalWebhook(hook)
+}
+
+// Update a webhook, it keeps its secret when no new one is given.
+func (w *webhookService) ServiceUpdateWebhook(id string, newWebhook []byte) error {
+	hook, err := decodeWebhook(newWebhook)
+	if err != nil {
+		return err
+	}
+
+	old, err := w.webhookRepo.GetWebhookID(id)
+	if err != nil {
+		return err
+	}
+
+	if hook.Secret == "" {
+		hook.Secret = old.Secret
+	}
+	hook.ID = id
+	hook.CreatedAt = old.CreatedAt
+	return w.webhookRepo.UpdateWebhook(id, hook)
+}
+
+// Delete a webhook. Its delivery log is kept, pending deliveries are not retried.
+func (w *webhookService) ServiceDeleteWebhook(id string) error {
+	if _, err := w.webhookRepo.GetWebhookID(id); err != nil {
+		return err
+	}
+	return w.webhookRepo.DeleteWebhook(id)
+}
+
+// Retrieve the deliveries of a webhook, newest first, optionally only those with the
+// given status.
+func (w *webhookService) ServiceGetDeliveries(id, status string) ([]byte, error) {
+	if _, err := w.webhookRepo.GetWebhookID(id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := w.findDeliveries(func(delivery models.WebhookDelivery) bool {
+		return delivery.WebhookID == id && (status == "" || delivery.Status == status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalWebhook(deliveries)
+}
+
+// Send a webhook.test event to a webhook right away, whatever events it subscribed to.
+// The response is the delivery after the first attempt, failures are retried as usual.
+func (w *webhookService) ServiceTestWebhook(id string) ([]byte, error) {
+	hook, err := w.webhookRepo.GetWebhookID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.Event{
+		Type:      models.EventWebhookTest,
+		Subject:   hook.ID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	delivery, err := w.createDelivery(hook, event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err = w.deliver(delivery.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	return marshalWebhook(delivery)
+}
+
+// Retrieve the deliveries of every webhook that ran out of attempts, newest first.
+func (w *webhookService) ServiceGetDeadLetters() ([]byte, error) {
+	deliveries, err := w.findDeliveries(func(delivery models.WebhookDelivery) bool {
+		return delivery.Status == models.DeliveryDead
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marshalWebhook(deliveries)
+}
+
+// Attempt a dead delivery once more, it stays dead when the attempt fails.
+func (w *webhookService) ServiceRetryDelivery(id string) ([]byte, error) {
+	w.mu.Lock()
+	delivery, err := w.deliveryRepo.GetWebhookDeliveryID(id)
+	if err == nil && delivery.Status != models.DeliveryDead {
+		slog.Error("Failed to retry delivery", "error", myerrors.ErrNotDeadLetter, "id", id, "status", delivery.Status)
+		err = myerrors.ErrNotDeadLetter
+	}
+	if err == nil {
+		delivery.Status = models.DeliveryPending
+		err = w.deliveryRepo.UpdateWebhookDelivery(id, delivery)
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err = w.deliver(id, true)
+	if err != nil {
+		return nil, err
+	}
+	return marshalWebhook(delivery)
+}
+
+// findDeliveries returns the deliveries that match, newest first.
+func (w *webhookService) findDeliveries(match func(models.WebhookDelivery) bool) ([]models.WebhookDelivery, error) {
+	w.mu.Lock()
+	deliveries, err := w.deliveryRepo.GetWebhookDeliveries()
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	found := []models.WebhookDelivery{}
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if match(deliveries[i]) {
+			found = append(found, deliveries[i])
+		}
+	}
+	return found, nil
+}
+
+// decodeWebhook unmarshals and validates a webhook, a webhook is active unless it
+// says otherwise.
+func decodeWebhook(data []byte) (models.Webhook, error) {
+	hook := models.Webhook{Active: true}
+	if err := json.Unmarshal(data, &hook); err != nil {
+		slog.Error("Failed to unmarshal", "error", err)
+		return models.Webhook{}, myerrors.ErrFailUnmarshal
+	}
+	hook.URL = strings.TrimSpace(hook.URL)
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+
+	if err := validation.CheckWebhook(hook); err != nil {
+		return models.Webhook{}, err
+	}
+	return hook, nil
+}
+
+func marshalWebhook(v any) ([]byte, error) {
+	jsonFile, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		slog.Error("Failed to marshal", "error", err)
+		return nil, myerrors.ErrFailMarshal
+	}
+	return jsonFile, nil
+}
@@ -0,0 +1,360 @@
+package csvencoder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// flushEvery is how many records are written between flushes of the underlying writer.
+const flushEvery = 100
+
+// value is a decoded JSON value that keeps the order of object keys, so arrays
+// written as JSON in a cell keep the order of the fields of the encoded struct.
+type value struct {
+	keys   []string
+	fields map[string]value
+	items  []value
+	scalar string
+	// json is the scalar as it was written, for arrays put in a single cell.
+	json string
+	kind int
+}
+
+const (
+	kindScalar = iota
+	kindObject
+	kindArray
+)
+
+type row struct {
+	keys   []string
+	values map[string]string
+}
+
+// Encode writes the JSON read from r as CSV. An array is written as one record per
+// element, anything else as a single record. Nested objects become dotted columns
+// ("ingredients.quantity") and arrays of scalars are joined with ";". The first array
+// of objects of a record repeats the record's columns on one row per element, any
+// other array of objects is written as JSON in a single cell, so sibling arrays never
+// multiply each other's rows. Columns are sorted by name.
+//
+// data is read twice, once for the columns and once for the rows, records are decoded
+// one at a time and rows are flushed every flushEvery records instead of building the
+// whole CSV first.
+func Encode(w io.Writer, data io.ReadSeeker) error {
+	var columns []string
+	seen := make(map[string]bool)
+	err := eachRecord(data, func(record value) error {
+		for _, r := range flatten(record, "", new(bool)) {
+			for _, key := range r.keys {
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Records may omit empty fields, sorting keeps the header the same whatever
+	// record comes first.
+	slices.Sort(columns)
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if len(columns) == 0 {
+		writer.Flush()
+		return writer.Error()
+	}
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	count := 0
+	err = eachRecord(data, func(record value) error {
+		for _, r := range flatten(record, "", new(bool)) {
+			line := make([]string, len(columns))
+			for i, column := range columns {
+				line[i] = r.values[column]
+			}
+			if err := writer.Write(line); err != nil {
+				return err
+			}
+		}
+
+		count++
+		if count%flushEvery == 0 {
+			writer.Flush()
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// eachRecord decodes the records one at a time instead of the whole document.
+func eachRecord(r io.Reader, fn func(record value) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != json.Delim('[') {
+		record, err := decodeValue(decoder, token)
+		if err != nil {
+			return err
+		}
+		return fn(record)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		record, err := decodeValue(decoder, token)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	_, err = decoder.Token()
+	return err
+}
+
+func decodeValue(decoder *json.Decoder, token json.Token) (value, error) {
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			v := value{kind: kindObject, fields: make(map[string]value)}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return value{}, err
+				}
+				key, _ := keyToken.(string)
+
+				next, err := decoder.Token()
+				if err != nil {
+					return value{}, err
+				}
+				field, err := decodeValue(decoder, next)
+				if err != nil {
+					return value{}, err
+				}
+
+				if _, ok := v.fields[key]; !ok {
+					v.keys = append(v.keys, key)
+				}
+				v.fields[key] = field
+			}
+			_, err := decoder.Token()
+			return v, err
+		}
+
+		v := value{kind: kindArray}
+		for decoder.More() {
+			next, err := decoder.Token()
+			if err != nil {
+				return value{}, err
+			}
+			item, err := decodeValue(decoder, next)
+			if err != nil {
+				return value{}, err
+			}
+			v.items = append(v.items, item)
+		}
+		_, err := decoder.Token()
+		return v, err
+	case nil:
+		return value{kind: kindScalar, json: "null"}, nil
+	case string:
+		quoted, _ := json.Marshal(t)
+		return value{kind: kindScalar, scalar: t, json: string(quoted)}, nil
+	default:
+		return value{kind: kindScalar, scalar: fmt.Sprint(t), json: fmt.Sprint(t)}, nil
+	}
+}
+
+// flatten turns a value into rows. expanded tells whether an array of objects of the
+// record was already spread over rows.
+func flatten(v value, prefix string, expanded *bool) []row {
+	switch v.kind {
+	case kindScalar:
+		return []row{singleCell(columnName(prefix, "value"), v.scalar)}
+	case kindArray:
+		if isScalarArray(v) {
+			return []row{singleCell(columnName(prefix, "value"), joinScalars(v))}
+		}
+		if *expanded {
+			return []row{singleCell(columnName(prefix, "value"), toJSON(v))}
+		}
+		*expanded = true
+		var rows []row
+		for _, item := range v.items {
+			rows = append(rows, flatten(item, prefix, expanded)...)
+		}
+		return rows
+	}
+
+	rows := []row{{values: make(map[string]string)}}
+	for _, key := range v.keys {
+		field := v.fields[key]
+		column := columnName(prefix, key)
+
+		switch {
+		case field.kind == kindScalar:
+			rows = combine(rows, []row{singleCell(column, field.scalar)})
+		case field.kind == kindArray && isScalarArray(field):
+			rows = combine(rows, []row{singleCell(column, joinScalars(field))})
+		case field.kind == kindArray && *expanded:
+			rows = combine(rows, []row{singleCell(column, toJSON(field))})
+		default:
+			nested := flatten(field, column, expanded)
+			if len(nested) > 0 {
+				rows = combine(rows, nested)
+			}
+		}
+	}
+	return rows
+}
+
+// combine returns every parent row extended with every child row. Only one array
+// of a record is spread over rows, so one of the two has a single row.
+func combine(parents, children []row) []row {
+	result := make([]row, 0, len(parents)*len(children))
+	for _, parent := range parents {
+		for _, child := range children {
+			r := row{
+				keys:   append(append([]string{}, parent.keys...), child.keys...),
+				values: make(map[string]string, len(parent.values)+len(child.values)),
+			}
+			for k, v := range parent.values {
+				r.values[k] = v
+			}
+			for k, v := range child.values {
+				r.values[k] = v
+			}
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func singleCell(column, cell string) row {
+	return row{keys: []string{column}, values: map[string]string{column: cell}}
+}
+
+func isScalarArray(v value) bool {
+	for _, item := range v.items {
+		if item.kind != kindScalar {
+			return false
+		}
+	}
+	return true
+}
+
+func joinScalars(v value) string {
+	parts := make([]string, len(v.items))
+	for i, item := range v.items {
+		parts[i] = item.scalar
+	}
+	return strings.Join(parts, ";")
+}
+
+// toJSON writes a value back as compact JSON, keeping the order of object keys.
+func toJSON(v value) string {
+	var b strings.Builder
+	writeJSON(&b, v)
+	return b.String()
+}
+
+func writeJSON(b *strings.Builder, v value) {
+	switch v.kind {
+	case kindScalar:
+		b.WriteString(v.json)
+	case kindArray:
+		b.WriteByte('[')
+		for i, item := range v.items {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSON(b, item)
+		}
+		b.WriteByte(']')
+	default:
+		b.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			quoted, _ := json.Marshal(key)
+			b.Write(quoted)
+			b.WriteByte(':')
+			writeJSON(b, v.fields[key])
+		}
+		b.WriteByte('}')
+	}
+}
+
+func columnName(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// Decode reads CSV with a header line, every row is returned as a map keyed by column name.
+func Decode(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	header := lines[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	rows := make([]map[string]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		r := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(line) {
+				r[column] = strings.TrimSpace(line[i])
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
@@ -0,0 +1,49 @@
+package csvencoder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "single record",
+			data: `{"name":"Latte","price":3.5,"id":"latte"}`,
+			want: "id,name,price",
+		},
+		{
+			name: "field omitted by the first record",
+			data: `[{"id":"a","name":"A"},{"id":"b","station":"bar","name":"B"}]`,
+			want: "id,name,station",
+		},
+		{
+			name: "field omitted by the second record",
+			data: `[{"id":"b","station":"bar","name":"B"},{"id":"a","name":"A"}]`,
+			want: "id,name,station",
+		},
+		{
+			name: "nested objects and arrays",
+			data: `[{"id":"a","items":[{"qty":1,"product_id":"x"}],"gift_cards":[{"code":"c"}]},{"id":"b","customer":{"name":"Bo"}}]`,
+			want: "customer.name,gift_cards,id,items.product_id,items.qty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Encode(&out, strings.NewReader(tt.data)); err != nil {
+				t.Fatal(err)
+			}
+			header, _, _ := strings.Cut(out.String(), "\n")
+			if header != tt.want {
+				t.Errorf("header = %q, want %q", header, tt.want)
+			}
+		})
+	}
+}
@@ -22,13 +22,19 @@ func CreateDir() {
 	createJSON()
 }
 
+// createJSON creates the data files that are missing, existing ones are left untouched.
 func createJSON() error {
 	data := []byte("[]")
 
-	fileNames := []string{"orders", "menu_items", "inventory_item"}
+	fileNames := []string{"orders", "menu_items", "inventory_item", "inventory_movements", "stock_alerts", "suppliers", "purchase_orders", "units", "inventory_lots", "stocktakes", "categories", "menu_prices", "recipe_versions", "customers", "loyalty_rules", "loyalty_transactions", "gift_cards", "gift_card_transactions", "webhooks", "webhook_deliveries"}
 
 	for _, fileName := range fileNames {
-		err := os.WriteFile(*config.Dir+"/"+fileName+".json", data, os.ModePerm)
+		path := *config.Dir + "/" + fileName + ".json"
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		err := os.WriteFile(path, data, os.ModePerm)
 		if err != nil {
 			slog.Error("Failed to write file", "error", err, "file name", fileName)
 			return myerrors.ErrFailWrite
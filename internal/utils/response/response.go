@@ -1,10 +1,14 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"hot-coffee/internal/utils/csvencoder"
 	"log/slog"
 	"net/http"
+	"path"
+	"strings"
 )
 
 type Error struct {
@@ -29,7 +33,7 @@ func SendError(w http.ResponseWriter, statusCode int, errorDescription string, e
 
 	output, err := json.MarshalIndent(error, "", "  ")
 	if err != nil {
-		slog.Error("Failed to marshal error ", err)
+		slog.Error("Failed to marshal error", "error", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -44,10 +48,44 @@ func SendMessage(w http.ResponseWriter, statusCode int, messageDescription strin
 
 	output, err := json.MarshalIndent(message, "", "  ")
 	if err != nil {
-		slog.Error("Failed to marshal error ", err)
+		slog.Error("Failed to marshal error", "error", err)
 		http.Error(w, "Internal error", http.StatusInternalServerError)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	w.Write(output)
 }
+
+// WantsCSV reports whether the client asked for CSV with ?format=csv or an Accept: text/csv header.
+func WantsCSV(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		return true
+	case "json":
+		return false
+	}
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(mediaRange), ";")
+		if strings.EqualFold(mediaType, "text/csv") {
+			return true
+		}
+	}
+	return false
+}
+
+// SendData writes the JSON produced by a service, converted to CSV when the client asked for it.
+func SendData(w http.ResponseWriter, r *http.Request, data []byte) {
+	if !WantsCSV(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+	if err := csvencoder.Encode(w, bytes.NewReader(data)); err != nil {
+		slog.Error("Failed to encode csv", "error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"hot-coffee/models"
+	"slices"
+	"time"
+)
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// IsDay tells whether name is one of "mon" to "sun".
+func IsDay(name string) bool {
+	return slices.Contains(dayNames, name)
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight.
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Open tells whether t falls into one of the schedules, no schedules means always open.
+// t must already be in the shop's time zone.
+func Open(schedules []models.Schedule, t time.Time) bool {
+	if len(schedules) == 0 {
+		return true
+	}
+	for _, s := range schedules {
+		if matches(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(s models.Schedule, t time.Time) bool {
+	date := t.Format(time.DateOnly)
+	if s.StartDate != "" && date < s.StartDate {
+		return false
+	}
+	if s.EndDate != "" && date > s.EndDate {
+		return false
+	}
+
+	clock := t.Hour()*60 + t.Minute()
+	from, to := 0, 24*60
+	if s.From != "" {
+		from, _ = ParseClock(s.From)
+	}
+	if s.To != "" {
+		to, _ = ParseClock(s.To)
+	}
+
+	if from <= to {
+		return onDay(s, t) && clock >= from && clock < to
+	}
+	// The window runs past midnight, its early hours belong to the previous day.
+	return (onDay(s, t) && clock >= from) || (onDay(s, t.AddDate(0, 0, -1)) && clock < to)
+}
+
+func onDay(s models.Schedule, t time.Time) bool {
+	return len(s.Days) == 0 || slices.Contains(s.Days, dayNames[t.Weekday()])
+}
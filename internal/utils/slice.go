@@ -3,6 +3,7 @@ package utils
 import (
 	"hot-coffee/models"
 	"log/slog"
+	"sort"
 )
 
 func DeleteElement(slice []models.OrderItem, index int) []models.OrderItem {
@@ -30,14 +31,26 @@ func DecreaseTemporaryStock(ingID string, qty float64, tempInventory []models.In
 }
 
 func AggregateOrderItems(items []models.OrderItem) []models.OrderItem {
-	aggregated := make(map[string]int)
+	positions := make(map[string]int)
+	result := make([]models.OrderItem, 0, len(items))
 	for _, item := range items {
-		aggregated[item.ProductID] += item.Quantity
-	}
+		choices := make([]string, 0, len(item.Choices))
+		for _, choice := range item.Choices {
+			choices = append(choices, choice.Slot+"="+choice.ProductID)
+		}
+		sort.Strings(choices)
 
-	result := make([]models.OrderItem, 0, len(aggregated))
-	for id, quantity := range aggregated {
-		result = append(result, models.OrderItem{ProductID: id, Quantity: quantity})
+		key := item.ProductID
+		for _, choice := range choices {
+			key += "|" + choice
+		}
+
+		if i, ok := positions[key]; ok {
+			result[i].Quantity += item.Quantity
+			continue
+		}
+		positions[key] = len(result)
+		result = append(result, item)
 	}
 
 	if len(items) != len(result) {
@@ -0,0 +1,113 @@
+package units
+
+import (
+	"hot-coffee/models"
+	"strings"
+
+	myerrors "hot-coffee/internal/myErrors"
+)
+
+// builtin units, each expressed in the base unit of its dimension.
+var builtin = map[string]models.Unit{
+	"mg":    {Name: "mg", Base: "g", Factor: 0.001},
+	"g":     {Name: "g", Base: "g", Factor: 1},
+	"kg":    {Name: "kg", Base: "g", Factor: 1000},
+	"ml":    {Name: "ml", Base: "ml", Factor: 1},
+	"cl":    {Name: "cl", Base: "ml", Factor: 10},
+	"l":     {Name: "l", Base: "ml", Factor: 1000},
+	"pcs":   {Name: "pcs", Base: "pcs", Factor: 1},
+	"dozen": {Name: "dozen", Base: "pcs", Factor: 12},
+}
+
+// Builtin returns the predefined units.
+func Builtin() []models.Unit {
+	names := []string{"mg", "g", "kg", "ml", "cl", "l", "pcs", "dozen"}
+	result := make([]models.Unit, 0, len(names))
+	for _, name := range names {
+		result = append(result, builtin[name])
+	}
+	return result
+}
+
+// IsBuiltin reports whether name is one of the predefined units.
+func IsBuiltin(name string) bool {
+	_, ok := builtin[Normalize(name)]
+	return ok
+}
+
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Convert expresses quantity given in unit from in unit to. Units that are neither
+// predefined nor custom only convert to themselves, so free-text units such as
+// "shots" keep working as long as recipe and inventory agree.
+func Convert(quantity float64, from, to string, custom []models.Unit) (float64, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == "" || to == "" || from == to {
+		return quantity, nil
+	}
+
+	fromBase, fromFactor := resolve(from, custom)
+	toBase, toFactor := resolve(to, custom)
+	if fromBase != toBase {
+		return 0, myerrors.ErrUnitMismatch
+	}
+
+	return quantity * fromFactor / toFactor, nil
+}
+
+// Cyclic reports whether following the bases of unit through the custom units leads
+// back to unit itself.
+func Cyclic(unit models.Unit, custom []models.Unit) bool {
+	name := Normalize(unit.Name)
+	base := Normalize(unit.Base)
+	seen := make(map[string]bool)
+	for !seen[base] {
+		if base == name {
+			return true
+		}
+		seen[base] = true
+		next, ok := findCustom(base, custom)
+		if !ok {
+			return false
+		}
+		base = Normalize(next.Base)
+	}
+	return false
+}
+
+// resolve returns the base unit of name and how many base units one name holds. Custom
+// units may be built on other custom units, the chain is followed to its end. A unit
+// whose chain loops only converts to itself.
+func resolve(name string, custom []models.Unit) (string, float64) {
+	start := name
+	factor := 1.0
+	seen := make(map[string]bool)
+	for {
+		unit, ok := findCustom(name, custom)
+		if !ok {
+			break
+		}
+		if seen[name] {
+			return start, 1
+		}
+		seen[name] = true
+		name = Normalize(unit.Base)
+		factor *= unit.Factor
+	}
+
+	if unit, ok := builtin[name]; ok {
+		return unit.Base, factor * unit.Factor
+	}
+	return name, factor
+}
+
+func findCustom(name string, custom []models.Unit) (models.Unit, bool) {
+	for _, unit := range custom {
+		if Normalize(unit.Name) == name {
+			return unit, true
+		}
+	}
+	return models.Unit{}, false
+}
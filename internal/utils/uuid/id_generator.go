@@ -9,6 +9,11 @@ const (
 )
 
 func RandStringBytesMask() string {
+	return NewID("order")
+}
+
+// NewID returns a random identifier ending with the given suffix, e.g. "xYzAbCdEfG_movement".
+func NewID(suffix string) string {
 	n := 10
 	b := make([]byte, n)
 	for i := 0; i < n; {
@@ -17,5 +22,5 @@ func RandStringBytesMask() string {
 			i++
 		}
 	}
-	return string(b) + "_order"
+	return string(b) + "_" + suffix
 }
@@ -1,8 +1,14 @@
 package validation
 
 import (
+	"hot-coffee/internal/utils/schedule"
 	"hot-coffee/models"
 	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	myerrors "hot-coffee/internal/myErrors"
 )
@@ -14,9 +20,15 @@ func IsJSON(contentType string) bool {
 	return false
 }
 
+// IsCSV accepts text/csv with or without parameters such as charset.
+func IsCSV(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(mediaType) == "text/csv"
+}
+
 func CheckOrder(newOrder models.Order) error {
-	if newOrder.CustomerName == "" {
-		slog.Error("Validation failed: Customer name is required")
+	if newOrder.CustomerName == "" && newOrder.CustomerID == "" {
+		slog.Error("Validation failed: Customer name or customer ID is required")
 		return myerrors.ErrNameRequired
 	}
 	if len(newOrder.Items) == 0 {
@@ -34,6 +46,20 @@ func CheckOrder(newOrder models.Order) error {
 			}
 		}
 	}
+	if len(newOrder.Redemptions) > 0 && newOrder.CustomerID == "" {
+		slog.Error("Validation failed: Customer ID is required to redeem rewards")
+		return myerrors.ErrCustomerRequired
+	}
+	for _, redemption := range newOrder.Redemptions {
+		if redemption.RuleID == "" {
+			slog.Error("Validation failed: Rule ID is required")
+			return myerrors.ErrIdRequired
+		}
+		if redemption.Rewards <= 0 {
+			slog.Error("Validation failed: Rewards must be >0", "rewards", redemption.Rewards)
+			return myerrors.ErrInvalidQuantity
+		}
+	}
 
 	return nil
 }
@@ -55,7 +81,8 @@ func CheckMenu(newMenu models.MenuItem) error {
 		slog.Error("Validation failed: Price field must be >=0")
 		return myerrors.ErrPriceRequired
 	}
-	if len(newMenu.Ingredients) == 0 {
+	isBundle := len(newMenu.Components) > 0 || len(newMenu.Slots) > 0
+	if len(newMenu.Ingredients) == 0 && !isBundle {
 		slog.Error("Validation failed: Ingredients field are required")
 		return myerrors.ErrIngredientsRequired
 	} else {
@@ -70,6 +97,44 @@ func CheckMenu(newMenu models.MenuItem) error {
 			}
 		}
 	}
+	for _, component := range newMenu.Components {
+		if component.ProductID == "" {
+			slog.Error("Validation failed: Component product ID field is required")
+			return myerrors.ErrIdRequired
+		}
+		if component.Quantity <= 0 {
+			slog.Error("Validation failed: Component quantity must be >0", "quantity", component.Quantity)
+			return myerrors.ErrInvalidQuantity
+		}
+	}
+	slots := make(map[string]bool)
+	for _, slot := range newMenu.Slots {
+		if slot.Name == "" {
+			slog.Error("Validation failed: Slot field is required")
+			return myerrors.ErrSlotRequired
+		}
+		if slots[slot.Name] {
+			slog.Error("Validation failed: Duplicate slot", "slot", slot.Name)
+			return myerrors.ErrDuplicateID
+		}
+		slots[slot.Name] = true
+		if len(slot.Options) == 0 {
+			slog.Error("Validation failed: Options field is required", "slot", slot.Name)
+			return myerrors.ErrOptionsRequired
+		}
+		if slot.Quantity <= 0 {
+			slog.Error("Validation failed: Slot quantity must be >0", "quantity", slot.Quantity)
+			return myerrors.ErrInvalidQuantity
+		}
+	}
+
+	if err := CheckStation(newMenu.Station); err != nil {
+		return err
+	}
+
+	if err := CheckSchedules(newMenu.Schedules); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -91,6 +156,267 @@ func CheckInventory(newInvent models.InventoryItem) error {
 		slog.Error("Validation failed: Unit field is required")
 		return myerrors.ErrUnitRequired
 	}
+	if newInvent.MinQuantity < 0 {
+		slog.Error("Validation failed: Min quantity field must be >=0")
+		return myerrors.ErrInvalidMinQuantity
+	}
+	if err := CheckAllergens(newInvent.Allergens); err != nil {
+		return err
+	}
+	if n := newInvent.Nutrition; n != nil && (n.Calories < 0 || n.Fat < 0 || n.Carbohydrates < 0 || n.Sugar < 0 || n.Protein < 0 || n.Salt < 0) {
+		slog.Error("Validation failed: Nutrition values must be >=0", "ingredient", newInvent.IngredientID)
+		return myerrors.ErrInvalidNutrition
+	}
+
+	return nil
+}
+
+// CheckAllergens verifies that every allergen is one of models.Allergens.
+func CheckAllergens(allergens []string) error {
+	for _, allergen := range allergens {
+		if !slices.Contains(models.Allergens, allergen) {
+			slog.Error("Validation failed: unknown allergen", "allergen", allergen)
+			return myerrors.ErrUnknownAllergen
+		}
+	}
+
+	return nil
+}
+
+func CheckCustomer(newCustomer models.Customer) error {
+	if strings.TrimSpace(newCustomer.Name) == "" {
+		slog.Error("Validation failed: Name field is required")
+		return myerrors.ErrNameRequired
+	}
+	if newCustomer.Email != "" {
+		if _, err := mail.ParseAddress(newCustomer.Email); err != nil {
+			slog.Error("Validation failed: invalid email", "email", newCustomer.Email)
+			return myerrors.ErrInvalidEmail
+		}
+	}
+
+	return nil
+}
+
+func CheckLoyaltyRule(newRule models.LoyaltyRule) error {
+	if strings.TrimSpace(newRule.Name) == "" {
+		slog.Error("Validation failed: Name field is required")
+		return myerrors.ErrNameRequired
+	}
+	switch newRule.Type {
+	case models.LoyaltyPoints:
+		if newRule.PointsPerUnit <= 0 {
+			slog.Error("Validation failed: Points per unit must be >0", "points_per_unit", newRule.PointsPerUnit)
+			return myerrors.ErrInvalidPointsRate
+		}
+		if newRule.RewardValue <= 0 {
+			slog.Error("Validation failed: Reward value must be >0", "reward_value", newRule.RewardValue)
+			return myerrors.ErrInvalidRewardValue
+		}
+	case models.LoyaltyStamps:
+		if newRule.CategoryID == "" {
+			slog.Error("Validation failed: Category ID is required for stamps")
+			return myerrors.ErrCategoryRequired
+		}
+		if newRule.RewardValue < 0 {
+			slog.Error("Validation failed: Reward value must be >=0", "reward_value", newRule.RewardValue)
+			return myerrors.ErrInvalidRewardValue
+		}
+	default:
+		slog.Error("Validation failed: invalid loyalty rule type", "type", newRule.Type)
+		return myerrors.ErrInvalidRuleType
+	}
+	if newRule.RewardCost <= 0 {
+		slog.Error("Validation failed: Reward cost must be >0", "reward_cost", newRule.RewardCost)
+		return myerrors.ErrInvalidRewardCost
+	}
+
+	return nil
+}
+
+func CheckAmount(amount float64) error {
+	if amount <= 0 {
+		slog.Error("Validation failed: Amount must be >0", "amount", amount)
+		return myerrors.ErrInvalidAmount
+	}
+
+	return nil
+}
+
+func CheckSupplier(newSupplier models.Supplier) error {
+	if newSupplier.ID == "" {
+		slog.Error("Validation failed: Supplier ID field is required")
+		return myerrors.ErrIdRequired
+	}
+	if newSupplier.Name == "" {
+		slog.Error("Validation failed: Name field is required")
+		return myerrors.ErrNameRequired
+	}
+	for _, item := range newSupplier.Items {
+		if item.IngredientID == "" {
+			slog.Error("Validation failed: Ingredient ID field is required")
+			return myerrors.ErrIdRequired
+		}
+		if item.Price < 0 {
+			slog.Error("Validation failed: Price field must be >=0", "price", item.Price)
+			return myerrors.ErrPriceRequired
+		}
+		if item.PackSize <= 0 {
+			slog.Error("Validation failed: Pack size field must be >0", "pack size", item.PackSize)
+			return myerrors.ErrInvalidPackSize
+		}
+	}
+
+	return nil
+}
+
+func CheckPurchaseOrder(newPurchaseOrder models.PurchaseOrder) error {
+	if newPurchaseOrder.SupplierID == "" {
+		slog.Error("Validation failed: Supplier ID field is required")
+		return myerrors.ErrIdRequired
+	}
+	if len(newPurchaseOrder.Lines) == 0 {
+		slog.Error("Validation failed: Lines field is required")
+		return myerrors.ErrLinesRequired
+	}
+	for _, line := range newPurchaseOrder.Lines {
+		if line.IngredientID == "" {
+			slog.Error("Validation failed: Ingredient ID field is required")
+			return myerrors.ErrIdRequired
+		}
+		if line.Packs <= 0 {
+			slog.Error("Validation failed: Packs must be >0", "packs", line.Packs)
+			return myerrors.ErrInvalidQuantity
+		}
+	}
+
+	return nil
+}
+
+func CheckAdjustment(adjustment models.StockAdjustment) error {
+	if adjustment.Delta == 0 {
+		slog.Error("Validation failed: Delta field must not be 0")
+		return myerrors.ErrInvalidDelta
+	}
+	if !slices.Contains(models.MovementReasons, adjustment.Reason) {
+		slog.Error("Validation failed: Unknown reason", "reason", adjustment.Reason)
+		return myerrors.ErrInvalidReason
+	}
+
+	return nil
+}
+
+func CheckUnit(newUnit models.Unit) error {
+	if newUnit.Name == "" {
+		slog.Error("Validation failed: Unit field is required")
+		return myerrors.ErrUnitRequired
+	}
+	if newUnit.Base == "" {
+		slog.Error("Validation failed: Base field is required")
+		return myerrors.ErrBaseRequired
+	}
+	if newUnit.Factor <= 0 {
+		slog.Error("Validation failed: Factor field must be >0", "factor", newUnit.Factor)
+		return myerrors.ErrInvalidFactor
+	}
+
+	return nil
+}
+
+func CheckStocktakeCounts(counts models.StocktakeCounts) error {
+	if len(counts.Counts) == 0 {
+		slog.Error("Validation failed: Counts field is required")
+		return myerrors.ErrCountsRequired
+	}
+	for _, count := range counts.Counts {
+		if count.IngredientID == "" {
+			slog.Error("Validation failed: Ingredient ID field is required")
+			return myerrors.ErrIdRequired
+		}
+		if count.CountedQuantity < 0 {
+			slog.Error("Validation failed: Counted quantity must be >=0", "quantity", count.CountedQuantity)
+			return myerrors.ErrInvalidQuantity
+		}
+	}
+
+	return nil
+}
+
+func CheckCategory(newCategory models.Category) error {
+	if newCategory.ID == "" {
+		slog.Error("Validation failed: Category ID field is required")
+		return myerrors.ErrIdRequired
+	}
+	if newCategory.Name == "" {
+		slog.Error("Validation failed: Name field is required")
+		return myerrors.ErrNameRequired
+	}
+	if err := CheckStation(newCategory.Station); err != nil {
+		return err
+	}
+
+	if err := CheckSchedules(newCategory.Schedules); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckStation accepts an empty station, which falls back to the category's or the bar.
+func CheckStation(station string) error {
+	if station != "" && station != models.StationBar && station != models.StationFood {
+		slog.Error("Validation failed: invalid station", "station", station)
+		return myerrors.ErrInvalidStation
+	}
+
+	return nil
+}
+
+func CheckSchedules(schedules []models.Schedule) error {
+	for _, s := range schedules {
+		for _, day := range s.Days {
+			if !schedule.IsDay(day) {
+				slog.Error("Validation failed: Unknown day", "day", day)
+				return myerrors.ErrInvalidSchedule
+			}
+		}
+		for _, clock := range []string{s.From, s.To} {
+			if _, err := schedule.ParseClock(clock); clock != "" && err != nil {
+				slog.Error("Validation failed: Time must be HH:MM", "time", clock)
+				return myerrors.ErrInvalidSchedule
+			}
+		}
+		for _, date := range []string{s.StartDate, s.EndDate} {
+			if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+				slog.Error("Validation failed: Date must be YYYY-MM-DD", "date", date)
+				return myerrors.ErrInvalidSchedule
+			}
+		}
+		if s.StartDate != "" && s.EndDate != "" && s.StartDate > s.EndDate {
+			slog.Error("Validation failed: Start date is after end date", "start", s.StartDate, "end", s.EndDate)
+			return myerrors.ErrInvalidSchedule
+		}
+	}
+
+	return nil
+}
+
+// CheckWebhook checks the URL of a webhook, which a webhook in test mode does not
+// need, and that its events are known event types or topics.
+func CheckWebhook(newWebhook models.Webhook) error {
+	if newWebhook.URL != "" || !newWebhook.TestMode {
+		target, err := url.Parse(newWebhook.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			slog.Error("Validation failed: URL must be absolute http or https", "url", newWebhook.URL)
+			return myerrors.ErrInvalidURL
+		}
+	}
+	for _, event := range newWebhook.Events {
+		if !slices.Contains(models.EventTypes, event) && !slices.Contains(models.Topics, event) {
+			slog.Error("Validation failed: unknown event type", "event", event)
+			return myerrors.ErrUnknownEventType
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of a signed delivery.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// How much of a receiver's response is kept in the delivery log.
+const maxResponse = 512
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Sign returns "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the secret. The timestamp is signed too so a captured request cannot be replayed
+// later on.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether signature is the signature of the body sent at timestamp.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Message is a signed JSON payload for a webhook.
+type Message struct {
+	URL        string
+	Secret     string
+	WebhookID  string
+	DeliveryID string
+	Event      string
+	Body       []byte
+}
+
+// Send posts the message, signed with the current time. It returns the status and the
+// start of the body of the response, a status outside 2xx is not an error.
+func Send(message Message) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, message.URL, bytes.NewReader(message.Body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, message.WebhookID)
+	req.Header.Set(HeaderDelivery, message.DeliveryID)
+	req.Header.Set(HeaderEvent, message.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(message.Secret, timestamp, message.Body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var client = &http.Client{Timeout: 5 * time.Second}
+
+// Notify posts payload as JSON to url in the background, failures are only logged.
+func Notify(url string, payload any) {
+	if url == "" {
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Failed to marshal webhook payload", "error", err)
+		return
+	}
+
+	go func() {
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			slog.Error("Failed to send webhook", "error", err, "url", url)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			slog.Error("Webhook rejected", "status", resp.StatusCode, "url", url)
+		}
+	}()
+}
@@ -0,0 +1,22 @@
+package models
+
+// Category groups menu items on the menu, inactive categories and their items are hidden.
+// Its schedules apply to all of its items on top of their own, its Station to the items
+// without a station of their own.
+type Category struct {
+	ID        string     `json:"category_id"`
+	Name      string     `json:"name"`
+	SortOrder int        `json:"sort_order"`
+	Active    bool       `json:"active"`
+	Station   string     `json:"station,omitempty"`
+	Schedules []Schedule `json:"schedules,omitempty"`
+}
+
+// MenuCategoryGroup is a category with its menu items in display order, items without
+// a category are grouped under an empty category_id.
+type MenuCategoryGroup struct {
+	CategoryID string         `json:"category_id"`
+	Name       string         `json:"name"`
+	SortOrder  int            `json:"sort_order"`
+	Items      []MenuItemView `json:"items"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// ComponentSalesReport attributes bundle sales back to the menu items they are made of.
+type ComponentSalesReport struct {
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Items []ComponentSales `json:"items"`
+}
+
+// ComponentSales splits the sales of a menu item into servings sold on their own and
+// servings sold inside bundles. Bundle revenue is shared between the components in
+// proportion to their menu prices.
+type ComponentSales struct {
+	ProductID      string  `json:"product_id"`
+	Name           string  `json:"name"`
+	DirectQuantity int     `json:"direct_quantity"`
+	BundleQuantity int     `json:"bundle_quantity"`
+	TotalQuantity  int     `json:"total_quantity"`
+	DirectRevenue  float64 `json:"direct_revenue"`
+	BundleRevenue  float64 `json:"bundle_revenue"`
+	TotalRevenue   float64 `json:"total_revenue"`
+}
@@ -0,0 +1,17 @@
+package models
+
+type ConsumptionReport struct {
+	From        string                  `json:"from"`
+	To          string                  `json:"to"`
+	Ingredients []IngredientConsumption `json:"ingredients"`
+}
+
+type IngredientConsumption struct {
+	IngredientID    string  `json:"ingredient_id"`
+	Unit            string  `json:"unit"`
+	Theoretical     float64 `json:"theoretical"`
+	Actual          float64 `json:"actual"`
+	Variance        float64 `json:"variance"`
+	VariancePercent float64 `json:"variance_percent"`
+	Flagged         bool    `json:"flagged"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Customer is a regular of the shop, orders may link to it by customer_id.
+type Customer struct {
+	ID               string `json:"customer_id"`
+	Name             string `json:"name"`
+	Email            string `json:"email,omitempty"`
+	Phone            string `json:"phone,omitempty"`
+	MarketingConsent bool   `json:"marketing_consent"`
+	CreatedAt        string `json:"created_at"`
+}
+
+// CustomerOrders is the order history of a customer. LifetimeSpend adds up the totals
+// of the closed orders, Visits counts the orders.
+type CustomerOrders struct {
+	CustomerID    string  `json:"customer_id"`
+	Name          string  `json:"name"`
+	LifetimeSpend float64 `json:"lifetime_spend"`
+	Visits        int     `json:"visits"`
+	LastVisit     string  `json:"last_visit,omitempty"`
+	Orders        []Order `json:"orders"`
+}
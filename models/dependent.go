@@ -0,0 +1,14 @@
+package models
+
+// Dependent is a record that still references a row being deleted.
+type Dependent struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// DeleteConflict is returned when a delete is refused because of its dependents.
+type DeleteConflict struct {
+	ErrorDescription string      `json:"ErrorDescription"`
+	Dependents       []Dependent `json:"dependents"`
+}
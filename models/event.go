@@ -0,0 +1,58 @@
+package models
+
+import "encoding/json"
+
+const (
+	TopicOrders    = "orders"
+	TopicMenu      = "menu"
+	TopicInventory = "inventory"
+
+	EventOrderCreated  = "order.created"
+	EventOrderUpdated  = "order.updated"
+	EventOrderReady    = "order.ready"
+	EventOrderClosed   = "order.closed"
+	EventOrderRefunded = "order.refunded"
+	EventOrderDeleted  = "order.deleted"
+	EventOrderRestored = "order.restored"
+
+	EventMenuCreated  = "menu.created"
+	EventMenuUpdated  = "menu.updated"
+	EventMenuDeleted  = "menu.deleted"
+	EventMenuRestored = "menu.restored"
+	EventMenuImported = "menu.imported"
+
+	EventInventoryCreated  = "inventory.created"
+	EventInventoryUpdated  = "inventory.updated"
+	EventInventoryDeleted  = "inventory.deleted"
+	EventInventoryRestored = "inventory.restored"
+	EventInventoryImported = "inventory.imported"
+	EventStockChanged      = "inventory.stock_changed"
+	EventStockLow          = "inventory.stock_low"
+	EventStockRecovered    = "inventory.stock_recovered"
+
+	// EventWebhookTest is only sent to a webhook that is tested, it is never published.
+	EventWebhookTest = "webhook.test"
+)
+
+var (
+	Topics = []string{TopicOrders, TopicMenu, TopicInventory}
+
+	EventTypes = []string{
+		EventOrderCreated, EventOrderUpdated, EventOrderReady, EventOrderClosed, EventOrderRefunded, EventOrderDeleted, EventOrderRestored,
+		EventMenuCreated, EventMenuUpdated, EventMenuDeleted, EventMenuRestored, EventMenuImported,
+		EventInventoryCreated, EventInventoryUpdated, EventInventoryDeleted, EventInventoryRestored, EventInventoryImported,
+		EventStockChanged, EventStockLow, EventStockRecovered,
+	}
+)
+
+// Event is something that happened to an order, a menu item or the inventory. IDs
+// grow with every event, Subject is the ID of what the event is about and Data its
+// state right after the event, if any.
+type Event struct {
+	ID        int64           `json:"id"`
+	Topic     string          `json:"topic"`
+	Type      string          `json:"type"`
+	Subject   string          `json:"subject,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt string          `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// StockForecast projects how long the current stock lasts at the average daily usage
+// of the lookback period and how much to reorder to cover the lead time and target cover.
+type StockForecast struct {
+	GeneratedAt     string               `json:"generated_at"`
+	LookbackDays    int                  `json:"lookback_days"`
+	LeadTimeDays    float64              `json:"lead_time_days"`
+	TargetCoverDays float64              `json:"target_cover_days"`
+	Ingredients     []IngredientForecast `json:"ingredients"`
+}
+
+// IngredientForecast leaves out days_of_cover and runs_out_at when the ingredient was not used,
+// and runs_out_at when the stock lasts more than ten years.
+type IngredientForecast struct {
+	IngredientID      string   `json:"ingredient_id"`
+	Unit              string   `json:"unit"`
+	Quantity          float64  `json:"quantity"`
+	OnOrder           float64  `json:"on_order"`
+	AverageDailyUsage float64  `json:"average_daily_usage"`
+	DaysOfCover       *float64 `json:"days_of_cover,omitempty"`
+	RunsOutAt         string   `json:"runs_out_at,omitempty"`
+	ReorderPoint      float64  `json:"reorder_point"`
+	ReorderNow        bool     `json:"reorder_now"`
+	SuggestedQuantity float64  `json:"suggested_quantity"`
+	SupplierID        string   `json:"supplier_id,omitempty"`
+	SuggestedPacks    float64  `json:"suggested_packs,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+const (
+	GiftCardActive = "active"
+	GiftCardVoid   = "void"
+
+	GiftCardIssue  = "issue"
+	GiftCardTopUp  = "top_up"
+	GiftCardRedeem = "redeem"
+	GiftCardRefund = "refund"
+	GiftCardVoided = "void"
+)
+
+// GiftCard is a prepaid card known by its generated Code. A card issued to a
+// CustomerID is store credit of that customer.
+type GiftCard struct {
+	Code       string  `json:"code"`
+	Balance    float64 `json:"balance"`
+	Status     string  `json:"status"`
+	CustomerID string  `json:"customer_id,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	VoidedAt   string  `json:"voided_at,omitempty"`
+}
+
+// GiftCardTransaction is an entry of the log of a card, it is never changed. Amount is
+// positive for money put on the card and negative for money taken off, Balance is the
+// balance of the card after it.
+type GiftCardTransaction struct {
+	ID        string  `json:"transaction_id"`
+	Code      string  `json:"code"`
+	Type      string  `json:"type"`
+	Amount    float64 `json:"amount"`
+	Balance   float64 `json:"balance"`
+	OrderID   string  `json:"order_id,omitempty"`
+	Note      string  `json:"note,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// GiftCardOperation is the body of issuing, topping up and redeeming a card. Paying an
+// order without an Amount pays what is left of the order total, as far as the balance goes.
+type GiftCardOperation struct {
+	Amount     float64 `json:"amount"`
+	CustomerID string  `json:"customer_id,omitempty"`
+	Code       string  `json:"code,omitempty"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// GiftCardPayment is the part of an order total paid with a card.
+type GiftCardPayment struct {
+	Code   string  `json:"code"`
+	Amount float64 `json:"amount"`
+}
+
+// GiftCardHistory is a card with its transactions, oldest first.
+type GiftCardHistory struct {
+	GiftCard
+	Transactions []GiftCardTransaction `json:"transactions"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Mode    string           `json:"mode"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
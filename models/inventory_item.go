@@ -1,8 +1,14 @@
 package models
 
+// InventoryItem is an ingredient in stock. Nutrition is given per one Unit. Archived
+// items have DeletedAt set.
 type InventoryItem struct {
-	IngredientID string  `json:"ingredient_id"`
-	Name         string  `json:"name"`
-	Quantity     float64 `json:"quantity"`
-	Unit         string  `json:"unit"`
+	IngredientID string     `json:"ingredient_id"`
+	Name         string     `json:"name"`
+	Quantity     float64    `json:"quantity"`
+	Unit         string     `json:"unit"`
+	MinQuantity  float64    `json:"min_quantity,omitempty"`
+	Allergens    []string   `json:"allergens,omitempty"`
+	Nutrition    *Nutrition `json:"nutrition,omitempty"`
+	DeletedAt    string     `json:"deleted_at,omitempty"`
 }
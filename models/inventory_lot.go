@@ -0,0 +1,15 @@
+package models
+
+// InventoryLot is a batch of an ingredient received together and sharing one expiry
+// date. Quantity is what is left of the batch, it is part of the ingredient's
+// inventory quantity.
+type InventoryLot struct {
+	ID              string  `json:"lot_id"`
+	IngredientID    string  `json:"ingredient_id"`
+	Quantity        float64 `json:"quantity"`
+	InitialQuantity float64 `json:"initial_quantity"`
+	ReceivedAt      string  `json:"received_at"`
+	ExpiresAt       string  `json:"expires_at"`
+	Expired         bool    `json:"expired,omitempty"`
+	ReferenceID     string  `json:"reference_id,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	MovementSale       = "sale"
+	MovementReceipt    = "receipt"
+	MovementWaste      = "waste"
+	MovementAdjustment = "adjustment"
+	MovementTransfer   = "transfer"
+	MovementRefund     = "refund"
+)
+
+// MovementReasons lists every reason code a stock change can be recorded with.
+var MovementReasons = []string{
+	MovementSale,
+	MovementReceipt,
+	MovementWaste,
+	MovementAdjustment,
+	MovementTransfer,
+	MovementRefund,
+}
+
+type InventoryMovement struct {
+	ID           string  `json:"movement_id"`
+	IngredientID string  `json:"ingredient_id"`
+	Delta        float64 `json:"delta"`
+	Reason       string  `json:"reason"`
+	ReferenceID  string  `json:"reference_id,omitempty"`
+	LotID        string  `json:"lot_id,omitempty"`
+	Note         string  `json:"note,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// StockAdjustment is a relative stock change requested through POST /inventory/{id}/adjust.
+type StockAdjustment struct {
+	Delta       float64 `json:"delta"`
+	Reason      string  `json:"reason"`
+	ReferenceID string  `json:"reference_id,omitempty"`
+	Note        string  `json:"note,omitempty"`
+}
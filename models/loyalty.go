@@ -0,0 +1,61 @@
+package models
+
+const (
+	LoyaltyPoints = "points"
+	LoyaltyStamps = "stamps"
+
+	LoyaltyEarn     = "earn"
+	LoyaltyRedeem   = "redeem"
+	LoyaltyReversal = "reversal"
+)
+
+// LoyaltyRule is how customers earn and spend rewards. A points rule earns PointsPerUnit
+// points per currency unit of a closed order, a stamps rule earns a stamp per item of
+// CategoryID. RewardCost points or stamps buy one reward worth RewardValue off an order,
+// a stamps rule without a RewardValue makes an item of its category free.
+type LoyaltyRule struct {
+	ID            string  `json:"rule_id"`
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	PointsPerUnit float64 `json:"points_per_unit,omitempty"`
+	CategoryID    string  `json:"category_id,omitempty"`
+	RewardCost    int     `json:"reward_cost"`
+	RewardValue   float64 `json:"reward_value,omitempty"`
+	Active        bool    `json:"active"`
+}
+
+// LoyaltyTransaction is an entry of the loyalty log, it is never changed. Amount is
+// positive for points or stamps earned and negative for the ones spent, a reversal
+// cancels an earlier entry of a refunded order.
+type LoyaltyTransaction struct {
+	ID         string `json:"transaction_id"`
+	CustomerID string `json:"customer_id"`
+	RuleID     string `json:"rule_id"`
+	OrderID    string `json:"order_id,omitempty"`
+	Type       string `json:"type"`
+	Amount     int    `json:"amount"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Redemption spends Rewards rewards of a rule on an order.
+type Redemption struct {
+	RuleID  string `json:"rule_id"`
+	Rewards int    `json:"rewards"`
+}
+
+// LoyaltyAccount is the balance of a customer under every rule, with the rewards it
+// buys, and the history of the customer newest first.
+type LoyaltyAccount struct {
+	CustomerID string               `json:"customer_id"`
+	Name       string               `json:"name"`
+	Balances   []LoyaltyBalance     `json:"balances"`
+	History    []LoyaltyTransaction `json:"history"`
+}
+
+type LoyaltyBalance struct {
+	RuleID  string `json:"rule_id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Balance int    `json:"balance"`
+	Rewards int    `json:"rewards"`
+}
@@ -1,14 +1,55 @@
 package models
 
+// MenuItem is made from Ingredients, or is a bundle sold at Price when it has
+// Components or Slots. A bundle may still list ingredients of its own, e.g. packaging.
+// Station is where the item is made, when empty the station of its category applies.
 type MenuItem struct {
 	ID          string               `json:"product_id"`
 	Name        string               `json:"name"`
 	Description string               `json:"description"`
 	Price       float64              `json:"price"`
 	Ingredients []MenuItemIngredient `json:"ingredients"`
+	CategoryID  string               `json:"category_id,omitempty"`
+	Station     string               `json:"station,omitempty"`
+	SortOrder   int                  `json:"sort_order,omitempty"`
+	Components  []BundleComponent    `json:"components,omitempty"`
+	Slots       []BundleSlot         `json:"slots,omitempty"`
+	Schedules   []Schedule           `json:"schedules,omitempty"`
+	// RecipeVersion is the current version of the recipe, kept by the service.
+	RecipeVersion int `json:"recipe_version,omitempty"`
+	// DeletedAt is set once the menu item is archived.
+	DeletedAt string `json:"deleted_at,omitempty"`
 }
 
+// BundleComponent is Quantity servings of another menu item that always come with a bundle.
+type BundleComponent struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// BundleSlot lets the customer pick one of Options, Quantity servings of it come with the bundle.
+type BundleSlot struct {
+	Name     string   `json:"slot"`
+	Options  []string `json:"options"`
+	Quantity int      `json:"quantity"`
+}
+
+// MenuItemIngredient is Quantity of an ingredient in Unit, an empty Unit means the
+// unit the ingredient is kept in the inventory.
 type MenuItemIngredient struct {
 	IngredientID string  `json:"ingredient_id"`
 	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit,omitempty"`
+}
+
+// MenuItemView is a menu item together with what the current inventory allows,
+// whether its schedules allow ordering it at the requested time and the allergens
+// and nutrition of one serving derived from its recipe.
+type MenuItemView struct {
+	MenuItem
+	Available   bool      `json:"available"`
+	MaxServings int       `json:"max_servings"`
+	Orderable   bool      `json:"orderable"`
+	Allergens   []string  `json:"allergens"`
+	Nutrition   Nutrition `json:"nutrition"`
 }
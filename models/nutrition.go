@@ -0,0 +1,18 @@
+package models
+
+// Allergens lists the allergens an ingredient can be flagged with.
+var Allergens = []string{
+	"gluten", "crustaceans", "eggs", "fish", "peanuts", "soy", "milk",
+	"nuts", "celery", "mustard", "sesame", "sulphites", "lupin", "molluscs",
+}
+
+// Nutrition holds energy in kcal and nutrients in grams, per unit of an inventory
+// ingredient or per serving of a menu item.
+type Nutrition struct {
+	Calories      float64 `json:"calories"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Sugar         float64 `json:"sugar"`
+	Protein       float64 `json:"protein"`
+	Salt          float64 `json:"salt"`
+}
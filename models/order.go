@@ -1,14 +1,42 @@
 package models
 
+// Order is placed by a walk-in CustomerName or by a known customer, linked by CustomerID.
+// Redemptions spend loyalty rewards of the customer, their Discount is taken off the Total.
+// GiftCards lists the parts of the Total paid with gift cards.
 type Order struct {
-	ID           string      `json:"order_id"`
-	CustomerName string      `json:"customer_name"`
-	Items        []OrderItem `json:"items"`
-	Status       string      `json:"status"`
-	CreatedAt    string      `json:"created_at"`
+	ID           string            `json:"order_id"`
+	CustomerName string            `json:"customer_name"`
+	CustomerID   string            `json:"customer_id,omitempty"`
+	Items        []OrderItem       `json:"items"`
+	Redemptions  []Redemption      `json:"redemptions,omitempty"`
+	Discount     float64           `json:"discount,omitempty"`
+	Total        float64           `json:"total,omitempty"`
+	GiftCards    []GiftCardPayment `json:"gift_cards,omitempty"`
+	Status       string            `json:"status"`
+	CreatedAt    string            `json:"created_at"`
+	ReadyAt      string            `json:"ready_at,omitempty"`
+	ClosedAt     string            `json:"closed_at,omitempty"`
+	RefundedAt   string            `json:"refunded_at,omitempty"`
+	DeletedAt    string            `json:"deleted_at,omitempty"`
 }
 
+// OrderItem orders Quantity servings of a product, for a bundle Choices picks an option
+// for every slot. Price is the unit price effective when the order was created, lines of
+// orders created before prices were kept on them have none, and
+// RecipeVersion the recipe the line is made with. Made is ticked off by the station
+// making the line. A bundle made at several stations lists in MadeStations the ones
+// that made their part, until all of them did and the line is made.
 type OrderItem struct {
+	ProductID     string            `json:"product_id"`
+	Quantity      int               `json:"quantity"`
+	Choices       []OrderItemChoice `json:"choices,omitempty"`
+	Price         *float64          `json:"price,omitempty"`
+	RecipeVersion int               `json:"recipe_version,omitempty"`
+	Made          bool              `json:"made,omitempty"`
+	MadeStations  []string          `json:"made_stations,omitempty"`
+}
+
+type OrderItemChoice struct {
+	Slot      string `json:"slot"`
 	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
 }
@@ -0,0 +1,17 @@
+package models
+
+// PriceChange sets the price of a menu item from EffectiveFrom on, until the next change.
+type PriceChange struct {
+	ID            string  `json:"price_id"`
+	ProductID     string  `json:"product_id"`
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// PriceHistory lists the price changes of a menu item, scheduled ones included.
+type PriceHistory struct {
+	ProductID    string        `json:"product_id"`
+	CurrentPrice float64       `json:"current_price"`
+	Prices       []PriceChange `json:"prices"`
+}
@@ -0,0 +1,42 @@
+package models
+
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+)
+
+type PurchaseOrder struct {
+	ID           string              `json:"purchase_order_id"`
+	SupplierID   string              `json:"supplier_id"`
+	Status       string              `json:"status"`
+	Lines        []PurchaseOrderLine `json:"lines"`
+	TotalCost    float64             `json:"total_cost"`
+	ReceivedCost float64             `json:"received_cost"`
+	CreatedAt    string              `json:"created_at"`
+	SentAt       string              `json:"sent_at,omitempty"`
+	ReceivedAt   string              `json:"received_at,omitempty"`
+}
+
+// PurchaseOrderLine orders Packs packs of an ingredient, pack size and price are
+// copied from the supplier when the line is created.
+type PurchaseOrderLine struct {
+	IngredientID  string  `json:"ingredient_id"`
+	Packs         float64 `json:"packs"`
+	PackSize      float64 `json:"pack_size"`
+	PackPrice     float64 `json:"pack_price"`
+	ReceivedPacks float64 `json:"received_packs"`
+}
+
+// PurchaseOrderReceipt lists what arrived, an empty receipt means everything outstanding.
+type PurchaseOrderReceipt struct {
+	Lines []PurchaseOrderReceiptLine `json:"lines"`
+}
+
+// PurchaseOrderReceiptLine may give an expiry date, the received quantity is then kept as a lot.
+type PurchaseOrderReceiptLine struct {
+	IngredientID string  `json:"ingredient_id"`
+	Packs        float64 `json:"packs"`
+	ExpiresAt    string  `json:"expires_at,omitempty"`
+}
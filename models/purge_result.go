@@ -0,0 +1,9 @@
+package models
+
+// PurgeResult counts the archived records removed for good.
+type PurgeResult struct {
+	ArchivedBefore string `json:"archived_before"`
+	MenuItems      int    `json:"menu_items"`
+	Inventory      int    `json:"inventory"`
+	Orders         int    `json:"orders"`
+}
@@ -0,0 +1,33 @@
+package models
+
+const (
+	StationBar  = "bar"
+	StationFood = "food"
+)
+
+// QueueStation lists the tickets a station still has to make, oldest order first.
+type QueueStation struct {
+	Station string        `json:"station"`
+	Tickets []QueueTicket `json:"tickets"`
+}
+
+// QueueTicket is the part of an open order made at one station.
+type QueueTicket struct {
+	OrderID      string      `json:"order_id"`
+	CustomerName string      `json:"customer_name"`
+	CreatedAt    string      `json:"created_at"`
+	Lines        []QueueLine `json:"lines"`
+}
+
+// QueueLine is a line of an order, Line is its position in the order's items. For a
+// bundle Components lists what one serving needs from the station, Made tells whether
+// the station made its part.
+type QueueLine struct {
+	Line       int               `json:"line"`
+	ProductID  string            `json:"product_id"`
+	Name       string            `json:"name"`
+	Quantity   int               `json:"quantity"`
+	Choices    []OrderItemChoice `json:"choices,omitempty"`
+	Components []BundleComponent `json:"components,omitempty"`
+	Made       bool              `json:"made"`
+}
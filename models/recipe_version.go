@@ -0,0 +1,28 @@
+package models
+
+// RecipeVersion is a snapshot of what a menu item is made of. Version 1 of a menu item
+// that existed before versions were kept has no created_at.
+type RecipeVersion struct {
+	ID          string               `json:"recipe_version_id"`
+	ProductID   string               `json:"product_id"`
+	Version     int                  `json:"version"`
+	Ingredients []MenuItemIngredient `json:"ingredients"`
+	Components  []BundleComponent    `json:"components,omitempty"`
+	Slots       []BundleSlot         `json:"slots,omitempty"`
+	CreatedAt   string               `json:"created_at,omitempty"`
+}
+
+// RecipeVersionView is a version with what changed since the previous one.
+type RecipeVersionView struct {
+	RecipeVersion
+	Changes []RecipeChange `json:"changes"`
+}
+
+// RecipeChange describes an ingredient, component or slot that was added, removed or changed.
+type RecipeChange struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// Schedule is a window in the shop's time zone when something can be ordered, empty
+// fields do not restrict. Days are "mon" to "sun", From and To are "HH:MM" (a window
+// ending before it starts runs past midnight) and the dates are inclusive "YYYY-MM-DD".
+type Schedule struct {
+	Days      []string `json:"days,omitempty"`
+	From      string   `json:"from,omitempty"`
+	To        string   `json:"to,omitempty"`
+	StartDate string   `json:"start_date,omitempty"`
+	EndDate   string   `json:"end_date,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+type StockAlert struct {
+	ID             string  `json:"alert_id"`
+	IngredientID   string  `json:"ingredient_id"`
+	Quantity       float64 `json:"quantity"`
+	MinQuantity    float64 `json:"min_quantity"`
+	Status         string  `json:"status"`
+	CreatedAt      string  `json:"created_at"`
+	AcknowledgedAt string  `json:"acknowledged_at,omitempty"`
+	ResolvedAt     string  `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+const (
+	StocktakeOpen      = "open"
+	StocktakeCommitted = "committed"
+)
+
+// Stocktake is a physical count of the shelves. While it is open the system quantities
+// of uncounted lines follow the inventory, a counted line keeps the system quantity of
+// the moment it was counted. Committing adds the variances to the inventory.
+type Stocktake struct {
+	ID          string          `json:"stocktake_id"`
+	Status      string          `json:"status"`
+	Note        string          `json:"note,omitempty"`
+	Lines       []StocktakeLine `json:"lines"`
+	StartedAt   string          `json:"started_at"`
+	CommittedAt string          `json:"committed_at,omitempty"`
+}
+
+type StocktakeLine struct {
+	IngredientID    string  `json:"ingredient_id"`
+	Unit            string  `json:"unit"`
+	SystemQuantity  float64 `json:"system_quantity"`
+	Counted         bool    `json:"counted"`
+	CountedQuantity float64 `json:"counted_quantity"`
+	Variance        float64 `json:"variance"`
+}
+
+// StocktakeCounts submits counted quantities, a later count of the same ingredient replaces the earlier one.
+type StocktakeCounts struct {
+	Counts []StocktakeCount `json:"counts"`
+}
+
+type StocktakeCount struct {
+	IngredientID    string  `json:"ingredient_id"`
+	CountedQuantity float64 `json:"counted_quantity"`
+}
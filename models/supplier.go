@@ -0,0 +1,16 @@
+package models
+
+type Supplier struct {
+	ID      string         `json:"supplier_id"`
+	Name    string         `json:"name"`
+	Contact string         `json:"contact,omitempty"`
+	Items   []SupplierItem `json:"items"`
+}
+
+// SupplierItem is an ingredient the supplier carries, sold in packs of PackSize
+// inventory units for Price each.
+type SupplierItem struct {
+	IngredientID string  `json:"ingredient_id"`
+	Price        float64 `json:"price"`
+	PackSize     float64 `json:"pack_size"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// Unit is a custom unit of measure, e.g. a "bag" of 500 "g". Factor is how many
+// Base units one Unit holds.
+type Unit struct {
+	Name   string  `json:"unit"`
+	Base   string  `json:"base"`
+	Factor float64 `json:"factor"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "encoding/json"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook subscribes a URL to events. Events lists event types or whole topics, an
+// empty list subscribes to everything. Payloads are signed with Secret, which is only
+// shown when the webhook is created. A webhook in test mode posts to a local test
+// server instead of its URL.
+type Webhook struct {
+	ID        string   `json:"webhook_id"`
+	URL       string   `json:"url,omitempty"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"`
+	Active    bool     `json:"active"`
+	TestMode  bool     `json:"test_mode,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// WebhookDelivery is an event sent to a webhook and every attempt to send it. It is
+// pending while attempts are left, dead once they ran out.
+type WebhookDelivery struct {
+	ID            string           `json:"delivery_id"`
+	WebhookID     string           `json:"webhook_id"`
+	EventID       int64            `json:"event_id,omitempty"`
+	EventType     string           `json:"event_type"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+	NextAttemptAt string           `json:"next_attempt_at,omitempty"`
+	CreatedAt     string           `json:"created_at"`
+	DeliveredAt   string           `json:"delivered_at,omitempty"`
+}
+
+type WebhookAttempt struct {
+	At         string `json:"at"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}